@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"weex-watchdog/internal/api/middleware"
+	"weex-watchdog/internal/model"
+	"weex-watchdog/internal/service"
+	"weex-watchdog/pkg/logger"
+)
+
+// APIKeyHandler API 密钥处理器
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+	auditService  *service.AuditService
+	logger        *logger.Logger
+}
+
+// NewAPIKeyHandler 创建 API 密钥处理器
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService, auditService *service.AuditService, logger *logger.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		auditService:  auditService,
+		logger:        logger,
+	}
+}
+
+// CreateAPIKeyRequest 创建 API 密钥请求
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required"` // 权限范围，如 orders:read
+	ExpiresAt *time.Time `json:"expires_at"`                // 为空时不过期
+}
+
+// GetAPIKeys 获取 API 密钥列表
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 || size > 100 {
+		size = 20
+	}
+
+	keys, total, err := h.apiKeyService.GetKeys(page, size)
+	if err != nil {
+		h.logger.WithField("error", err).Error("Failed to get api keys")
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Message: "Failed to get API keys: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginationResponse{
+		Success: true,
+		Message: "API keys retrieved successfully",
+		Data:    keys,
+		Total:   total,
+		Page:    page,
+		Size:    size,
+	})
+}
+
+// CreateAPIKey 创建 API 密钥，明文密钥只在本次响应中返回
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, Response{Success: false, Message: "Not logged in"})
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	scopes := make(model.Permissions, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		scopes = append(scopes, model.Permission(scope))
+	}
+
+	key, plaintext, err := h.apiKeyService.CreateKey(user, middleware.EffectivePermissions(c), req.Name, scopes, req.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAPIKey) {
+			c.JSON(http.StatusBadRequest, Response{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+		h.logger.WithField("error", err).Error("Failed to create api key")
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Message: "Failed to create API key: " + err.Error(),
+		})
+		return
+	}
+	h.auditService.Record(auditActor(c), model.AuditActionAPIKeyCreate, "api_key", strconv.FormatUint(uint64(key.ID), 10), nil, key)
+
+	c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Message: "API key created successfully, store the key now as it will not be shown again",
+		Data: gin.H{
+			"key":     plaintext,
+			"api_key": key,
+		},
+	})
+}
+
+// RevokeAPIKey 吊销 API 密钥
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Invalid API key ID",
+		})
+		return
+	}
+
+	key, err := h.apiKeyService.GetKeyByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, Response{
+			Success: false,
+			Message: "API key not found",
+		})
+		return
+	}
+
+	if err := h.apiKeyService.RevokeKey(uint(id)); err != nil {
+		h.logger.WithField("error", err).Error("Failed to revoke api key")
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Message: "Failed to revoke API key: " + err.Error(),
+		})
+		return
+	}
+	after := *key
+	if after.RevokedAt == nil {
+		now := time.Now()
+		after.RevokedAt = &now
+	}
+	h.auditService.Record(auditActor(c), model.AuditActionAPIKeyRevoke, "api_key", c.Param("id"), key, &after)
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: "API key revoked successfully",
+	})
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"weex-watchdog/internal/api/middleware"
+	"weex-watchdog/internal/service"
+	"weex-watchdog/pkg/logger"
+)
+
+// AuditHandler 审计日志处理器
+type AuditHandler struct {
+	auditService *service.AuditService
+	logger       *logger.Logger
+}
+
+// NewAuditHandler 创建审计日志处理器
+func NewAuditHandler(auditService *service.AuditService, logger *logger.Logger) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+// GetAuditLogs 获取审计日志
+// 可按 actor、action（以 . 结尾时按前缀匹配）、target_type、target_id、start_time、end_time 筛选，
+// 时间支持 RFC3339 或 2006-01-02，只写日期时 end_time 包含当天
+func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 || size > 100 {
+		size = 20
+	}
+
+	filters := map[string]interface{}{
+		"actor":       c.Query("actor"),
+		"action":      c.Query("action"),
+		"target_type": c.Query("target_type"),
+		"target_id":   c.Query("target_id"),
+	}
+	for _, key := range []string{"start_time", "end_time"} {
+		value := c.Query(key)
+		if value == "" {
+			continue
+		}
+		t, dateOnly, err := parseAuditTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, Response{
+				Success: false,
+				Message: "Invalid " + key + ", expected RFC3339 or YYYY-MM-DD",
+			})
+			return
+		}
+		if key == "end_time" && dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		filters[key] = t
+	}
+
+	logs, total, err := h.auditService.GetLogs(filters, page, size)
+	if err != nil {
+		h.logger.WithField("error", err).Error("Failed to get audit logs")
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Message: "Failed to get audit logs: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginationResponse{
+		Success: true,
+		Message: "Audit logs retrieved successfully",
+		Data:    logs,
+		Total:   total,
+		Page:    page,
+		Size:    size,
+	})
+}
+
+// parseAuditTime 解析 RFC3339 或 YYYY-MM-DD（本地时区）
+func parseAuditTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	return t, true, err
+}
+
+// auditActor 当前请求的操作人
+func auditActor(c *gin.Context) service.AuditActor {
+	actor := service.AuditActor{ClientIP: c.ClientIP()}
+	if user := middleware.CurrentUser(c); user != nil {
+		actor.UserID = &user.ID
+		actor.Username = user.Username
+	}
+	if key := middleware.CurrentAPIKey(c); key != nil {
+		actor.APIKeyID = &key.ID
+	}
+	return actor
+}
@@ -1,28 +1,31 @@
 package handler
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"weex-watchdog/pkg/crypto"
+	"weex-watchdog/internal/api/middleware"
+	"weex-watchdog/internal/model"
+	"weex-watchdog/internal/service"
 	"weex-watchdog/pkg/logger"
 )
 
 // AuthHandler 处理认证请求
 type AuthHandler struct {
-	username string
-	password string
-	aesKey   []byte
-	logger   *logger.Logger
+	authService  *service.AuthService
+	auditService *service.AuditService
+	logger       *logger.Logger
 }
 
 // NewAuthHandler 创建一个新的AuthHandler
-func NewAuthHandler(username, password string, aesKey []byte, logger *logger.Logger) *AuthHandler {
+func NewAuthHandler(authService *service.AuthService, auditService *service.AuditService, logger *logger.Logger) *AuthHandler {
 	return &AuthHandler{
-		username: username,
-		password: password,
-		aesKey:   aesKey,
-		logger:   logger,
+		authService:  authService,
+		auditService: auditService,
+		logger:       logger,
 	}
 }
 
@@ -32,6 +35,17 @@ type LoginPayload struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// RefreshPayload 刷新令牌请求的结构
+type RefreshPayload struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// ChangePasswordPayload 修改密码请求的结构
+type ChangePasswordPayload struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
 // Login 处理用户登录
 func (h *AuthHandler) Login(c *gin.Context) {
 	var payload LoginPayload
@@ -40,18 +54,152 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	if payload.Username == h.username && payload.Password == h.password {
-		// 凭证正确，生成token
-		plaintext := payload.Username + ":" + payload.Password
-		token, err := crypto.EncryptAES(h.aesKey, plaintext)
-		if err != nil {
-			h.logger.Error("Failed to encrypt token", "error", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to generate token"})
+	tokens, user, err := h.authService.Login(payload.Username, payload.Password, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		var locked *service.LoginLockedError
+		if errors.As(err, &locked) {
+			retryAfter := int(math.Ceil(locked.RetryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"success":     false,
+				"message":     "Too many failed login attempts, please try again later",
+				"retry_after": retryAfter,
+			})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"success": true, "token": token})
-	} else {
-		// 凭证错误
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Invalid username or password"})
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			h.auditService.Record(service.AuditActor{Username: payload.Username, ClientIP: c.ClientIP()},
+				model.AuditActionLoginFailed, "user", payload.Username, nil, nil)
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Invalid username or password"})
+			return
+		}
+		h.logger.WithField("error", err).Error("Failed to login")
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to generate token"})
+		return
 	}
+	h.auditService.Record(service.AuditActor{UserID: &user.ID, Username: user.Username, ClientIP: c.ClientIP()},
+		model.AuditActionLogin, "user", strconv.FormatUint(uint64(user.ID), 10), nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":            true,
+		"token":              tokens.AccessToken,
+		"refresh_token":      tokens.RefreshToken,
+		"expires_at":         tokens.AccessExpiresAt,
+		"refresh_expires_at": tokens.RefreshExpiresAt,
+		"user":               user,
+	})
+}
+
+// Refresh 使用刷新令牌换取新的令牌
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var payload RefreshPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request payload"})
+		return
+	}
+
+	tokens, err := h.authService.Refresh(payload.RefreshToken, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Invalid or expired refresh token"})
+			return
+		}
+		h.logger.WithField("error", err).Error("Failed to refresh token")
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to refresh token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":            true,
+		"token":              tokens.AccessToken,
+		"refresh_token":      tokens.RefreshToken,
+		"expires_at":         tokens.AccessExpiresAt,
+		"refresh_expires_at": tokens.RefreshExpiresAt,
+	})
+}
+
+// Logout 注销当前会话
+func (h *AuthHandler) Logout(c *gin.Context) {
+	session := middleware.CurrentSession(c)
+	if session == nil {
+		c.JSON(http.StatusUnauthorized, Response{Success: false, Message: "Not logged in"})
+		return
+	}
+
+	if err := h.authService.Logout(session.ID); err != nil {
+		h.logger.WithField("error", err).Error("Failed to logout")
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Message: "Failed to logout: " + err.Error(),
+		})
+		return
+	}
+	h.auditService.Record(auditActor(c), model.AuditActionLogout, "user", strconv.FormatUint(uint64(session.UserID), 10), nil, nil)
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: "Logged out successfully",
+	})
+}
+
+// Me 获取当前登录用户及其权限，使用 API 密钥时同时返回密钥信息
+func (h *AuthHandler) Me(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, Response{Success: false, Message: "Not logged in"})
+		return
+	}
+
+	data := gin.H{
+		"user":        user,
+		"permissions": middleware.EffectivePermissions(c),
+	}
+	if key := middleware.CurrentAPIKey(c); key != nil {
+		data["api_key"] = key
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    data,
+	})
+}
+
+// ChangePassword 修改当前用户密码，修改后需要重新登录
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	user := middleware.CurrentUser(c)
+	if user == nil || middleware.CurrentSession(c) == nil {
+		c.JSON(http.StatusUnauthorized, Response{Success: false, Message: "Not logged in"})
+		return
+	}
+
+	var payload ChangePasswordPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.authService.ChangePassword(user, payload.OldPassword, payload.NewPassword); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCredentials):
+			c.JSON(http.StatusBadRequest, Response{Success: false, Message: "Old password is incorrect"})
+		case errors.Is(err, service.ErrInvalidUser):
+			c.JSON(http.StatusBadRequest, Response{Success: false, Message: err.Error()})
+		default:
+			h.logger.WithField("error", err).Error("Failed to change password")
+			c.JSON(http.StatusInternalServerError, Response{
+				Success: false,
+				Message: "Failed to change password: " + err.Error(),
+			})
+		}
+		return
+	}
+	h.auditService.Record(auditActor(c), model.AuditActionPasswordChange, "user", strconv.FormatUint(uint64(user.ID), 10), nil, nil)
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: "Password changed successfully, please login again",
+	})
 }
@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
@@ -31,13 +33,15 @@ type PaginationResponse struct {
 // TraderHandler 交易员处理器
 type TraderHandler struct {
 	traderService *service.TraderService
+	auditService  *service.AuditService
 	logger        *logger.Logger
 }
 
 // NewTraderHandler 创建交易员处理器
-func NewTraderHandler(traderService *service.TraderService, logger *logger.Logger) *TraderHandler {
+func NewTraderHandler(traderService *service.TraderService, auditService *service.AuditService, logger *logger.Logger) *TraderHandler {
 	return &TraderHandler{
 		traderService: traderService,
+		auditService:  auditService,
 		logger:        logger,
 	}
 }
@@ -47,6 +51,8 @@ type CreateTraderRequest struct {
 	TraderUserID    string `json:"trader_user_id" binding:"required"`
 	TraderName      string `json:"trader_name"`
 	MonitorInterval int    `json:"monitor_interval"`
+	// NotifyInitialSnapshot 首次拉取时是否推送已有持仓
+	NotifyInitialSnapshot bool `json:"notify_initial_snapshot"`
 }
 
 // CreateTrader 创建交易员监控
@@ -65,6 +71,8 @@ func (h *TraderHandler) CreateTrader(c *gin.Context) {
 		TraderName:      req.TraderName,
 		MonitorInterval: req.MonitorInterval,
 		IsActive:        true,
+
+		NotifyInitialSnapshot: req.NotifyInitialSnapshot,
 	}
 
 	if trader.MonitorInterval <= 0 {
@@ -79,6 +87,7 @@ func (h *TraderHandler) CreateTrader(c *gin.Context) {
 		})
 		return
 	}
+	h.auditService.Record(auditActor(c), model.AuditActionTraderCreate, "trader", trader.TraderUserID, nil, trader)
 
 	c.JSON(http.StatusCreated, Response{
 		Success: true,
@@ -148,7 +157,9 @@ func (h *TraderHandler) UpdateTrader(c *gin.Context) {
 		return
 	}
 
+	before := *trader
 	trader.TraderName = req.TraderName
+	trader.NotifyInitialSnapshot = req.NotifyInitialSnapshot
 	if req.MonitorInterval > 0 {
 		trader.MonitorInterval = req.MonitorInterval
 	}
@@ -161,6 +172,7 @@ func (h *TraderHandler) UpdateTrader(c *gin.Context) {
 		})
 		return
 	}
+	h.auditService.Record(auditActor(c), model.AuditActionTraderUpdate, "trader", trader.TraderUserID, &before, trader)
 
 	c.JSON(http.StatusOK, Response{
 		Success: true,
@@ -180,6 +192,15 @@ func (h *TraderHandler) DeleteTrader(c *gin.Context) {
 		return
 	}
 
+	trader, err := h.traderService.GetTraderByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, Response{
+			Success: false,
+			Message: "Trader not found",
+		})
+		return
+	}
+
 	if err := h.traderService.DeleteTrader(uint(id)); err != nil {
 		h.logger.WithField("error", err).Error("Failed to delete trader")
 		c.JSON(http.StatusInternalServerError, Response{
@@ -188,6 +209,7 @@ func (h *TraderHandler) DeleteTrader(c *gin.Context) {
 		})
 		return
 	}
+	h.auditService.Record(auditActor(c), model.AuditActionTraderDelete, "trader", trader.TraderUserID, trader, nil)
 
 	c.JSON(http.StatusOK, Response{
 		Success: true,
@@ -217,6 +239,15 @@ func (h *TraderHandler) ToggleMonitor(c *gin.Context) {
 		return
 	}
 
+	trader, err := h.traderService.GetTraderByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, Response{
+			Success: false,
+			Message: "Trader not found",
+		})
+		return
+	}
+
 	if err := h.traderService.ToggleTraderMonitor(uint(id), req.IsActive); err != nil {
 		h.logger.WithField("error", err).Error("Failed to toggle trader monitor")
 		c.JSON(http.StatusInternalServerError, Response{
@@ -225,6 +256,9 @@ func (h *TraderHandler) ToggleMonitor(c *gin.Context) {
 		})
 		return
 	}
+	after := *trader
+	after.IsActive = req.IsActive
+	h.auditService.Record(auditActor(c), model.AuditActionTraderToggle, "trader", trader.TraderUserID, trader, &after)
 
 	c.JSON(http.StatusOK, Response{
 		Success: true,
@@ -278,6 +312,29 @@ func (h *TraderAnalysisHandler) AnalyzeTrader(c *gin.Context) {
 	})
 }
 
+// MonitorHandler 监控状态处理器
+type MonitorHandler struct {
+	monitorService *service.MonitorService
+	logger         *logger.Logger
+}
+
+// NewMonitorHandler 创建监控状态处理器
+func NewMonitorHandler(monitorService *service.MonitorService, logger *logger.Logger) *MonitorHandler {
+	return &MonitorHandler{
+		monitorService: monitorService,
+		logger:         logger,
+	}
+}
+
+// GetStatus 获取监控工作池状态
+func (h *MonitorHandler) GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: "Monitor status retrieved successfully",
+		Data:    h.monitorService.GetStats(),
+	})
+}
+
 // OrderHandler 订单处理器
 type OrderHandler struct {
 	orderService *service.OrderService
@@ -389,13 +446,15 @@ func (h *OrderHandler) GetStatistics(c *gin.Context) {
 // NotificationHandler 通知处理器
 type NotificationHandler struct {
 	notificationService *service.NotificationService
+	auditService        *service.AuditService
 	logger              *logger.Logger
 }
 
 // NewNotificationHandler 创建通知处理器
-func NewNotificationHandler(notificationService *service.NotificationService, logger *logger.Logger) *NotificationHandler {
+func NewNotificationHandler(notificationService *service.NotificationService, auditService *service.AuditService, logger *logger.Logger) *NotificationHandler {
 	return &NotificationHandler{
 		notificationService: notificationService,
+		auditService:        auditService,
 		logger:              logger,
 	}
 }
@@ -413,7 +472,15 @@ func (h *NotificationHandler) GetNotificationLogs(c *gin.Context) {
 		size = 20
 	}
 
-	logs, total, err := h.notificationService.GetNotificationLogs(traderUserID, page, size)
+	filters := map[string]interface{}{
+		"status":  strings.ToUpper(c.Query("status")),
+		"channel": strings.ToLower(c.Query("channel")),
+	}
+	if subscriberID, err := strconv.ParseUint(c.Query("subscriber_id"), 10, 32); err == nil {
+		filters["subscriber_id"] = uint(subscriberID)
+	}
+
+	logs, total, err := h.notificationService.GetNotificationLogs(traderUserID, filters, page, size)
 	if err != nil {
 		h.logger.WithField("error", err).Error("Failed to get notification logs")
 		c.JSON(http.StatusInternalServerError, Response{
@@ -440,6 +507,7 @@ func (h *NotificationHandler) TestNotification(c *gin.Context) {
 	if message == "" {
 		message = "This is a test notification"
 	}
+	h.auditService.Record(auditActor(c), model.AuditActionNotificationTest, "notification", "", nil, nil)
 	if err := h.notificationService.TestNotification(message); err != nil {
 		h.logger.WithField("error", err).Error("Failed to send test notification")
 		c.JSON(http.StatusInternalServerError, Response{
@@ -455,3 +523,83 @@ func (h *NotificationHandler) TestNotification(c *gin.Context) {
 		Message: "Test notification sent successfully",
 	})
 }
+
+// ResendNotification 手动重发通知
+func (h *NotificationHandler) ResendNotification(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Invalid notification ID",
+		})
+		return
+	}
+
+	log, err := h.notificationService.ResendNotification(uint(id))
+	if log != nil {
+		h.auditService.Record(auditActor(c), model.AuditActionNotificationResend, "notification", strconv.FormatUint(id, 10), nil, nil)
+	}
+	if log == nil {
+		status := http.StatusNotFound
+		if errors.Is(err, service.ErrNotificationNotResendable) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, Response{
+			Success: false,
+			Message: "Failed to resend notification: " + err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		h.logger.WithFields(map[string]interface{}{
+			"notification_id": id,
+			"error":           err,
+		}).Error("Failed to resend notification")
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Message: "Failed to resend notification: " + err.Error(),
+			Data:    log,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: "Notification resent successfully",
+		Data:    log,
+	})
+}
+
+// PreviewTemplateRequest 模板预览请求
+type PreviewTemplateRequest struct {
+	Channel  string `json:"channel" binding:"required"`
+	Event    string `json:"event" binding:"required"` // NEW_ORDER / ORDER_CLOSED / ORDER_UPDATED
+	Template string `json:"template"`                 // 待预览的模板内容，为空时预览渠道当前生效的模板
+	Format   string `json:"format"`                   // html 使用 html/template，其他使用 text/template
+}
+
+// PreviewTemplate 使用示例订单预览通知模板
+func (h *NotificationHandler) PreviewTemplate(c *gin.Context) {
+	var req PreviewTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	preview, err := h.notificationService.PreviewTemplate(req.Channel, model.NotificationType(strings.ToUpper(req.Event)), req.Template, strings.EqualFold(req.Format, "html"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Failed to preview template: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    preview,
+	})
+}
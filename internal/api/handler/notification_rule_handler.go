@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"weex-watchdog/internal/model"
+	"weex-watchdog/internal/service"
+	"weex-watchdog/pkg/logger"
+)
+
+// NotificationRuleHandler 通知过滤规则处理器
+type NotificationRuleHandler struct {
+	ruleService  *service.NotificationRuleService
+	auditService *service.AuditService
+	logger       *logger.Logger
+}
+
+// NewNotificationRuleHandler 创建通知过滤规则处理器
+func NewNotificationRuleHandler(ruleService *service.NotificationRuleService, auditService *service.AuditService, logger *logger.Logger) *NotificationRuleHandler {
+	return &NotificationRuleHandler{
+		ruleService:  ruleService,
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+// NotificationRuleRequest 创建/更新过滤规则请求
+type NotificationRuleRequest struct {
+	Name           string  `json:"name" binding:"required"`
+	TraderUserID   string  `json:"trader_user_id"`            // 为空时对所有交易员生效
+	Action         string  `json:"action" binding:"required"` // INCLUDE / EXCLUDE
+	ContractSymbol string  `json:"contract_symbol"`
+	PositionSide   string  `json:"position_side"`
+	MinLeverage    float64 `json:"min_leverage"`
+	MinNotional    float64 `json:"min_notional"`
+	QuietStart     string  `json:"quiet_start"` // HH:MM
+	QuietEnd       string  `json:"quiet_end"`   // HH:MM
+	IsActive       *bool   `json:"is_active"`   // 为空时默认启用
+}
+
+// apply 将请求内容写入规则
+func (req *NotificationRuleRequest) apply(rule *model.NotificationRule) {
+	rule.Name = req.Name
+	rule.TraderUserID = req.TraderUserID
+	rule.Action = model.RuleAction(req.Action)
+	rule.ContractSymbol = req.ContractSymbol
+	rule.PositionSide = req.PositionSide
+	rule.MinLeverage = req.MinLeverage
+	rule.MinNotional = req.MinNotional
+	rule.QuietStart = req.QuietStart
+	rule.QuietEnd = req.QuietEnd
+	rule.IsActive = req.IsActive == nil || *req.IsActive
+}
+
+// GetRules 获取过滤规则列表
+func (h *NotificationRuleHandler) GetRules(c *gin.Context) {
+	rules, err := h.ruleService.GetRules(c.Query("trader_user_id"))
+	if err != nil {
+		h.logger.WithField("error", err).Error("Failed to get notification rules")
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Message: "Failed to get notification rules: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    rules,
+	})
+}
+
+// GetRule 获取单个过滤规则
+func (h *NotificationRuleHandler) GetRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Invalid rule ID",
+		})
+		return
+	}
+
+	rule, err := h.ruleService.GetRuleByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, Response{
+			Success: false,
+			Message: "Rule not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    rule,
+	})
+}
+
+// CreateRule 创建过滤规则
+func (h *NotificationRuleHandler) CreateRule(c *gin.Context) {
+	var req NotificationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	rule := &model.NotificationRule{}
+	req.apply(rule)
+
+	if err := h.ruleService.CreateRule(rule); err != nil {
+		h.respondSaveError(c, "create", err)
+		return
+	}
+	h.auditService.Record(auditActor(c), model.AuditActionRuleCreate, "notification_rule", strconv.FormatUint(uint64(rule.ID), 10), nil, rule)
+
+	c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Message: "Rule created successfully",
+		Data:    rule,
+	})
+}
+
+// UpdateRule 更新过滤规则
+func (h *NotificationRuleHandler) UpdateRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Invalid rule ID",
+		})
+		return
+	}
+
+	rule, err := h.ruleService.GetRuleByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, Response{
+			Success: false,
+			Message: "Rule not found",
+		})
+		return
+	}
+
+	var req NotificationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Invalid request: " + err.Error(),
+		})
+		return
+	}
+	before := *rule
+	req.apply(rule)
+
+	if err := h.ruleService.UpdateRule(rule); err != nil {
+		h.respondSaveError(c, "update", err)
+		return
+	}
+	h.auditService.Record(auditActor(c), model.AuditActionRuleUpdate, "notification_rule", c.Param("id"), &before, rule)
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: "Rule updated successfully",
+		Data:    rule,
+	})
+}
+
+// DeleteRule 删除过滤规则
+func (h *NotificationRuleHandler) DeleteRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Invalid rule ID",
+		})
+		return
+	}
+
+	rule, err := h.ruleService.GetRuleByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, Response{
+			Success: false,
+			Message: "Rule not found",
+		})
+		return
+	}
+
+	if err := h.ruleService.DeleteRule(uint(id)); err != nil {
+		h.logger.WithField("error", err).Error("Failed to delete notification rule")
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Message: "Failed to delete rule: " + err.Error(),
+		})
+		return
+	}
+	h.auditService.Record(auditActor(c), model.AuditActionRuleDelete, "notification_rule", c.Param("id"), rule, nil)
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: "Rule deleted successfully",
+	})
+}
+
+// respondSaveError 参数错误返回 400，其他错误返回 500
+func (h *NotificationRuleHandler) respondSaveError(c *gin.Context, action string, err error) {
+	if errors.Is(err, service.ErrInvalidRule) {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.logger.WithField("error", err).Error("Failed to " + action + " notification rule")
+	c.JSON(http.StatusInternalServerError, Response{
+		Success: false,
+		Message: "Failed to " + action + " rule: " + err.Error(),
+	})
+}
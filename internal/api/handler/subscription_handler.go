@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"weex-watchdog/internal/model"
+	"weex-watchdog/internal/service"
+	"weex-watchdog/pkg/logger"
+)
+
+// SubscriptionHandler 通知订阅处理器
+type SubscriptionHandler struct {
+	subscriptionService *service.SubscriptionService
+	auditService        *service.AuditService
+	logger              *logger.Logger
+}
+
+// NewSubscriptionHandler 创建通知订阅处理器
+func NewSubscriptionHandler(subscriptionService *service.SubscriptionService, auditService *service.AuditService, logger *logger.Logger) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		subscriptionService: subscriptionService,
+		auditService:        auditService,
+		logger:              logger,
+	}
+}
+
+// SubscriptionRequest 订阅的交易员及事件类型
+type SubscriptionRequest struct {
+	TraderUserID string   `json:"trader_user_id" binding:"required"`
+	EventTypes   []string `json:"event_types"` // NEW_ORDER / ORDER_CLOSED / ORDER_UPDATED，为空时接收全部事件
+}
+
+// SubscriberRequest 创建/更新订阅人请求
+type SubscriberRequest struct {
+	Name          string                `json:"name" binding:"required"`
+	Channel       string                `json:"channel" binding:"required"`
+	Address       string                `json:"address" binding:"required"`
+	IsActive      *bool                 `json:"is_active"` // 为空时默认启用
+	Subscriptions []SubscriptionRequest `json:"subscriptions" binding:"dive"`
+}
+
+// apply 将请求内容写入订阅人
+func (req *SubscriberRequest) apply(subscriber *model.Subscriber) {
+	subscriber.Name = req.Name
+	subscriber.Channel = req.Channel
+	subscriber.Address = req.Address
+	subscriber.IsActive = req.IsActive == nil || *req.IsActive
+
+	subscriber.Subscriptions = make([]model.Subscription, 0, len(req.Subscriptions))
+	for _, item := range req.Subscriptions {
+		var eventTypes model.NotificationTypes
+		for _, eventType := range item.EventTypes {
+			eventTypes = append(eventTypes, model.NotificationType(eventType))
+		}
+		subscriber.Subscriptions = append(subscriber.Subscriptions, model.Subscription{
+			TraderUserID: item.TraderUserID,
+			EventTypes:   eventTypes,
+		})
+	}
+}
+
+// GetSubscribers 获取订阅人列表
+func (h *SubscriptionHandler) GetSubscribers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 || size > 100 {
+		size = 20
+	}
+
+	subscribers, total, err := h.subscriptionService.GetSubscribers(page, size)
+	if err != nil {
+		h.logger.WithField("error", err).Error("Failed to get subscribers")
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Message: "Failed to get subscribers: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginationResponse{
+		Success: true,
+		Message: "Subscribers retrieved successfully",
+		Data:    subscribers,
+		Total:   total,
+		Page:    page,
+		Size:    size,
+	})
+}
+
+// GetSubscriber 获取单个订阅人
+func (h *SubscriptionHandler) GetSubscriber(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Invalid subscriber ID",
+		})
+		return
+	}
+
+	subscriber, err := h.subscriptionService.GetSubscriberByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, Response{
+			Success: false,
+			Message: "Subscriber not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    subscriber,
+	})
+}
+
+// CreateSubscriber 创建订阅人
+func (h *SubscriptionHandler) CreateSubscriber(c *gin.Context) {
+	var req SubscriberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	subscriber := &model.Subscriber{}
+	req.apply(subscriber)
+
+	if err := h.subscriptionService.CreateSubscriber(subscriber); err != nil {
+		h.respondSaveError(c, "create", err)
+		return
+	}
+	h.auditService.Record(auditActor(c), model.AuditActionSubscriberCreate, "subscriber", strconv.FormatUint(uint64(subscriber.ID), 10), nil, subscriber)
+
+	c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Message: "Subscriber created successfully",
+		Data:    subscriber,
+	})
+}
+
+// UpdateSubscriber 更新订阅人，订阅列表整体替换
+func (h *SubscriptionHandler) UpdateSubscriber(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Invalid subscriber ID",
+		})
+		return
+	}
+
+	subscriber, err := h.subscriptionService.GetSubscriberByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, Response{
+			Success: false,
+			Message: "Subscriber not found",
+		})
+		return
+	}
+
+	var req SubscriberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Invalid request: " + err.Error(),
+		})
+		return
+	}
+	before := *subscriber
+	req.apply(subscriber)
+
+	if err := h.subscriptionService.UpdateSubscriber(subscriber); err != nil {
+		h.respondSaveError(c, "update", err)
+		return
+	}
+	h.auditService.Record(auditActor(c), model.AuditActionSubscriberUpdate, "subscriber", c.Param("id"), &before, subscriber)
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: "Subscriber updated successfully",
+		Data:    subscriber,
+	})
+}
+
+// DeleteSubscriber 删除订阅人
+func (h *SubscriptionHandler) DeleteSubscriber(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Invalid subscriber ID",
+		})
+		return
+	}
+
+	subscriber, err := h.subscriptionService.GetSubscriberByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, Response{
+			Success: false,
+			Message: "Subscriber not found",
+		})
+		return
+	}
+
+	if err := h.subscriptionService.DeleteSubscriber(uint(id)); err != nil {
+		h.logger.WithField("error", err).Error("Failed to delete subscriber")
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Message: "Failed to delete subscriber: " + err.Error(),
+		})
+		return
+	}
+	h.auditService.Record(auditActor(c), model.AuditActionSubscriberDelete, "subscriber", c.Param("id"), subscriber, nil)
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: "Subscriber deleted successfully",
+	})
+}
+
+// respondSaveError 参数错误返回 400，其他错误返回 500
+func (h *SubscriptionHandler) respondSaveError(c *gin.Context, action string, err error) {
+	if errors.Is(err, service.ErrInvalidSubscriber) {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.logger.WithField("error", err).Error("Failed to " + action + " subscriber")
+	c.JSON(http.StatusInternalServerError, Response{
+		Success: false,
+		Message: "Failed to " + action + " subscriber: " + err.Error(),
+	})
+}
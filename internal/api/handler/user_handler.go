@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"weex-watchdog/internal/api/middleware"
+	"weex-watchdog/internal/model"
+	"weex-watchdog/internal/service"
+	"weex-watchdog/pkg/logger"
+)
+
+// UserHandler 用户管理处理器
+type UserHandler struct {
+	authService  *service.AuthService
+	auditService *service.AuditService
+	logger       *logger.Logger
+}
+
+// NewUserHandler 创建用户管理处理器
+func NewUserHandler(authService *service.AuthService, auditService *service.AuditService, logger *logger.Logger) *UserHandler {
+	return &UserHandler{
+		authService:  authService,
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+// CreateUserRequest 创建用户请求
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Role     string `json:"role"`      // admin / operator / viewer，为空时默认 viewer
+	IsActive *bool  `json:"is_active"` // 为空时默认启用
+}
+
+// UpdateUserRequest 更新用户请求，字段为空时不修改
+type UpdateUserRequest struct {
+	Password *string `json:"password"`
+	Role     *string `json:"role"`
+	IsActive *bool   `json:"is_active"`
+}
+
+// GetUsers 获取用户列表
+func (h *UserHandler) GetUsers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 || size > 100 {
+		size = 20
+	}
+
+	users, total, err := h.authService.GetUsers(page, size)
+	if err != nil {
+		h.logger.WithField("error", err).Error("Failed to get users")
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Message: "Failed to get users: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginationResponse{
+		Success: true,
+		Message: "Users retrieved successfully",
+		Data:    users,
+		Total:   total,
+		Page:    page,
+		Size:    size,
+	})
+}
+
+// CreateUser 创建用户
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	var req CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	role := model.RoleViewer
+	if req.Role != "" {
+		role = model.Role(req.Role)
+	}
+
+	user, err := h.authService.CreateUser(req.Username, req.Password, role, req.IsActive == nil || *req.IsActive)
+	if err != nil {
+		h.respondSaveError(c, "create", err)
+		return
+	}
+	h.auditService.Record(auditActor(c), model.AuditActionUserCreate, "user", strconv.FormatUint(uint64(user.ID), 10), nil, user)
+
+	c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Message: "User created successfully",
+		Data:    user,
+	})
+}
+
+// UpdateUser 重置用户密码、修改角色或启用/停用用户
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	user, err := h.authService.GetUserByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, Response{
+			Success: false,
+			Message: "User not found",
+		})
+		return
+	}
+
+	var req UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Invalid request: " + err.Error(),
+		})
+		return
+	}
+	if req.IsActive != nil && !*req.IsActive && isCurrentUser(c, user.ID) {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Cannot deactivate the current user",
+		})
+		return
+	}
+
+	var role *model.Role
+	if req.Role != nil {
+		value := model.Role(*req.Role)
+		if value != user.Role && isCurrentUser(c, user.ID) {
+			c.JSON(http.StatusBadRequest, Response{
+				Success: false,
+				Message: "Cannot change the role of the current user",
+			})
+			return
+		}
+		role = &value
+	}
+
+	before := *user
+	if err := h.authService.UpdateUser(user, req.Password, role, req.IsActive); err != nil {
+		h.respondSaveError(c, "update", err)
+		return
+	}
+	after := interface{}(user)
+	if req.Password != nil {
+		// 密码哈希不出现在 JSON 中，单独标记密码已重置
+		after = struct {
+			*model.User
+			PasswordReset bool `json:"password_reset"`
+		}{user, true}
+	}
+	h.auditService.Record(auditActor(c), model.AuditActionUserUpdate, "user", c.Param("id"), &before, after)
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: "User updated successfully",
+		Data:    user,
+	})
+}
+
+// DeleteUser 删除用户
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+	if isCurrentUser(c, uint(id)) {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Cannot delete the current user",
+		})
+		return
+	}
+
+	user, err := h.authService.GetUserByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, Response{
+			Success: false,
+			Message: "User not found",
+		})
+		return
+	}
+
+	if err := h.authService.DeleteUser(uint(id)); err != nil {
+		h.logger.WithField("error", err).Error("Failed to delete user")
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Message: "Failed to delete user: " + err.Error(),
+		})
+		return
+	}
+	h.auditService.Record(auditActor(c), model.AuditActionUserDelete, "user", c.Param("id"), user, nil)
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Message: "User deleted successfully",
+	})
+}
+
+// respondSaveError 参数错误返回 400，其他错误返回 500
+func (h *UserHandler) respondSaveError(c *gin.Context, action string, err error) {
+	if errors.Is(err, service.ErrInvalidUser) {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.logger.WithField("error", err).Error("Failed to " + action + " user")
+	c.JSON(http.StatusInternalServerError, Response{
+		Success: false,
+		Message: "Failed to " + action + " user: " + err.Error(),
+	})
+}
+
+// isCurrentUser 是否为当前登录用户
+func isCurrentUser(c *gin.Context, userID uint) bool {
+	current := middleware.CurrentUser(c)
+	return current != nil && current.ID == userID
+}
@@ -1,11 +1,12 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"weex-watchdog/pkg/crypto"
+	"weex-watchdog/internal/model"
 )
 
 // CORSMiddleware 创建一个CORS中间件
@@ -13,7 +14,7 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, Token")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, Token, X-API-Key")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -25,26 +26,87 @@ func CORSMiddleware() gin.HandlerFunc {
 	}
 }
 
+// Authenticator 校验访问令牌
+type Authenticator interface {
+	Authenticate(accessToken string) (*model.User, *model.UserSession, error)
+}
+
+// APIKeyAuthenticator 校验 API 密钥
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(key string) (*model.User, *model.APIKey, error)
+}
+
+// 认证通过后写入 gin.Context 的键
+const (
+	contextUserKey    = "auth_user"
+	contextSessionKey = "auth_session"
+	contextAPIKeyKey  = "auth_api_key"
+)
+
 // AuthMiddleware 创建一个Token验证中间件
-func AuthMiddleware(aesKey []byte, validUsername, validPassword string) gin.HandlerFunc {
+// 登录令牌从 Token 请求头或 Authorization: Bearer 中读取；
+// API 密钥从 X-API-Key 请求头读取，也可以放在 Token 或 Authorization 中
+func AuthMiddleware(auth Authenticator, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := c.GetHeader("Token")
+		token := requestToken(c)
 		if token == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Authorization token required"})
 			c.Abort()
 			return
 		}
 
-		decrypted, err := crypto.DecryptAES(aesKey, token)
+		if strings.HasPrefix(token, model.APIKeyPrefix) {
+			user, key, err := apiKeys.AuthenticateAPIKey(token)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Invalid, expired or revoked API key"})
+				c.Abort()
+				return
+			}
+			c.Set(contextUserKey, user)
+			c.Set(contextAPIKeyKey, key)
+			c.Next()
+			return
+		}
+
+		user, session, err := auth.Authenticate(token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Invalid token"})
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Invalid or expired token"})
 			c.Abort()
 			return
 		}
 
-		parts := strings.Split(decrypted, ":")
-		if len(parts) != 2 || parts[0] != validUsername || parts[1] != validPassword {
-			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Token validation failed"})
+		c.Set(contextUserKey, user)
+		c.Set(contextSessionKey, session)
+		c.Next()
+	}
+}
+
+// RequirePermission 创建一个权限校验中间件，需在 AuthMiddleware 之后使用
+// 使用 API 密钥时还需要密钥范围包含该权限，缺少权限时返回 403 并说明缺少的权限
+func RequirePermission(permission model.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := CurrentUser(c)
+		if user == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Authorization token required"})
+			c.Abort()
+			return
+		}
+
+		if !user.Role.HasPermission(permission) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success":    false,
+				"message":    fmt.Sprintf("Permission denied: role %s is missing permission %s", user.Role, permission),
+				"permission": permission,
+			})
+			c.Abort()
+			return
+		}
+		if key := CurrentAPIKey(c); key != nil && !key.Scopes.Contains(permission) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success":    false,
+				"message":    fmt.Sprintf("Permission denied: API key %s is missing scope %s", key.Name, permission),
+				"permission": permission,
+			})
 			c.Abort()
 			return
 		}
@@ -52,3 +114,68 @@ func AuthMiddleware(aesKey []byte, validUsername, validPassword string) gin.Hand
 		c.Next()
 	}
 }
+
+// CurrentUser 获取当前登录用户
+func CurrentUser(c *gin.Context) *model.User {
+	if value, ok := c.Get(contextUserKey); ok {
+		if user, ok := value.(*model.User); ok {
+			return user
+		}
+	}
+	return nil
+}
+
+// CurrentSession 获取当前会话
+func CurrentSession(c *gin.Context) *model.UserSession {
+	if value, ok := c.Get(contextSessionKey); ok {
+		if session, ok := value.(*model.UserSession); ok {
+			return session
+		}
+	}
+	return nil
+}
+
+// CurrentAPIKey 获取当前请求使用的 API 密钥，使用登录令牌时返回 nil
+func CurrentAPIKey(c *gin.Context) *model.APIKey {
+	if value, ok := c.Get(contextAPIKeyKey); ok {
+		if key, ok := value.(*model.APIKey); ok {
+			return key
+		}
+	}
+	return nil
+}
+
+// EffectivePermissions 当前请求拥有的权限
+func EffectivePermissions(c *gin.Context) []model.Permission {
+	user := CurrentUser(c)
+	if user == nil {
+		return nil
+	}
+	key := CurrentAPIKey(c)
+	if key == nil {
+		return user.Role.Permissions()
+	}
+
+	permissions := make([]model.Permission, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		if user.Role.HasPermission(scope) {
+			permissions = append(permissions, scope)
+		}
+	}
+	return permissions
+}
+
+// requestToken 读取请求中的访问令牌或 API 密钥
+func requestToken(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	if token := c.GetHeader("Token"); token != "" {
+		return token
+	}
+	authorization := c.GetHeader("Authorization")
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "Bearer ") {
+		return strings.TrimSpace(authorization[7:])
+	}
+	return ""
+}
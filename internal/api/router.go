@@ -3,6 +3,7 @@ package api
 import (
 	"weex-watchdog/internal/api/handler"
 	"weex-watchdog/internal/api/middleware"
+	"weex-watchdog/internal/model"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,11 +13,16 @@ type Router struct {
 	traderHandler       *handler.TraderHandler
 	orderHandler        *handler.OrderHandler
 	notificationHandler *handler.NotificationHandler
+	subscriptionHandler *handler.SubscriptionHandler
+	ruleHandler         *handler.NotificationRuleHandler
 	analysisHandler     *handler.TraderAnalysisHandler
+	monitorHandler      *handler.MonitorHandler
 	authHandler         *handler.AuthHandler
-	aesKey              []byte
-	username 			string
-	password 			string
+	userHandler         *handler.UserHandler
+	apiKeyHandler       *handler.APIKeyHandler
+	auditHandler        *handler.AuditHandler
+	authenticator       middleware.Authenticator
+	apiKeyAuthenticator middleware.APIKeyAuthenticator
 }
 
 // NewRouter 创建新的路由器
@@ -24,21 +30,31 @@ func NewRouter(
 	traderHandler *handler.TraderHandler,
 	orderHandler *handler.OrderHandler,
 	notificationHandler *handler.NotificationHandler,
+	subscriptionHandler *handler.SubscriptionHandler,
+	ruleHandler *handler.NotificationRuleHandler,
 	analysisHandler *handler.TraderAnalysisHandler,
+	monitorHandler *handler.MonitorHandler,
 	authHandler *handler.AuthHandler,
-	aesKey []byte,
-	username string,
-	password string,
+	userHandler *handler.UserHandler,
+	apiKeyHandler *handler.APIKeyHandler,
+	auditHandler *handler.AuditHandler,
+	authenticator middleware.Authenticator,
+	apiKeyAuthenticator middleware.APIKeyAuthenticator,
 ) *Router {
 	return &Router{
 		traderHandler:       traderHandler,
 		orderHandler:        orderHandler,
 		notificationHandler: notificationHandler,
+		subscriptionHandler: subscriptionHandler,
+		ruleHandler:         ruleHandler,
 		analysisHandler:     analysisHandler,
+		monitorHandler:      monitorHandler,
 		authHandler:         authHandler,
-		aesKey:              aesKey,
-		username:          	 username,
-		password: 			 password,
+		userHandler:         userHandler,
+		apiKeyHandler:       apiKeyHandler,
+		auditHandler:        auditHandler,
+		authenticator:       authenticator,
+		apiKeyAuthenticator: apiKeyAuthenticator,
 	}
 }
 
@@ -54,35 +70,91 @@ func (r *Router) SetupRoutes(engine *gin.Engine) {
 
 	// 登录接口，不受保护
 	apiV1.POST("/login", r.authHandler.Login)
+	apiV1.POST("/auth/refresh", r.authHandler.Refresh)
 
 	// 受保护的API组
+	// 每个接口按所需权限校验当前用户的角色，/auth 下的接口只需登录
 	protected := apiV1.Group("/")
-	protected.Use(middleware.AuthMiddleware(r.aesKey, r.username, r.password))
+	protected.Use(middleware.AuthMiddleware(r.authenticator, r.apiKeyAuthenticator))
+	require := middleware.RequirePermission
 	{
+		// 当前用户
+		auth := protected.Group("/auth")
+		{
+			auth.POST("/logout", r.authHandler.Logout)
+			auth.GET("/me", r.authHandler.Me)
+			auth.PUT("/password", r.authHandler.ChangePassword)
+		}
+
+		// 用户管理
+		users := protected.Group("/users")
+		users.Use(require(model.PermissionUsersManage))
+		{
+			users.GET("", r.userHandler.GetUsers)
+			users.POST("", r.userHandler.CreateUser)
+			users.PUT("/:id", r.userHandler.UpdateUser)
+			users.DELETE("/:id", r.userHandler.DeleteUser)
+		}
+
+		// API 密钥
+		apiKeys := protected.Group("/api-keys")
+		apiKeys.Use(require(model.PermissionAPIKeysManage))
+		{
+			apiKeys.GET("", r.apiKeyHandler.GetAPIKeys)
+			apiKeys.POST("", r.apiKeyHandler.CreateAPIKey)
+			apiKeys.DELETE("/:id", r.apiKeyHandler.RevokeAPIKey)
+		}
+
+		// 审计日志
+		protected.GET("/audit", require(model.PermissionAuditRead), r.auditHandler.GetAuditLogs)
+
 		// 交易员管理
 		traders := protected.Group("/traders")
 		{
-			traders.GET("", r.traderHandler.GetTraders)
-			traders.POST("", r.traderHandler.CreateTrader)
-			traders.PUT("/:id", r.traderHandler.UpdateTrader)
-			traders.DELETE("/:id", r.traderHandler.DeleteTrader)
-			traders.POST("/:id/toggle", r.traderHandler.ToggleMonitor)
-			traders.GET("/:id/analysis", r.analysisHandler.AnalyzeTrader)
+			traders.GET("", require(model.PermissionTradersRead), r.traderHandler.GetTraders)
+			traders.POST("", require(model.PermissionTradersWrite), r.traderHandler.CreateTrader)
+			traders.PUT("/:id", require(model.PermissionTradersWrite), r.traderHandler.UpdateTrader)
+			traders.DELETE("/:id", require(model.PermissionTradersDelete), r.traderHandler.DeleteTrader)
+			traders.POST("/:id/toggle", require(model.PermissionTradersWrite), r.traderHandler.ToggleMonitor)
+			traders.GET("/:id/analysis", require(model.PermissionAnalysisRead), r.analysisHandler.AnalyzeTrader)
 		}
 
 		// 订单管理
 		orders := protected.Group("/orders")
 		{
-			orders.GET("", r.orderHandler.GetOrderHistory)
-			orders.GET("/active", r.orderHandler.GetActiveOrders)
-			orders.GET("/statistics", r.orderHandler.GetStatistics)
+			orders.GET("", require(model.PermissionOrdersRead), r.orderHandler.GetOrderHistory)
+			orders.GET("/active", require(model.PermissionOrdersRead), r.orderHandler.GetActiveOrders)
+			orders.GET("/statistics", require(model.PermissionOrdersRead), r.orderHandler.GetStatistics)
 		}
 
 		// 通知管理
 		notifications := protected.Group("/notifications")
 		{
-			notifications.GET("", r.notificationHandler.GetNotificationLogs)
-			notifications.POST("/test", r.notificationHandler.TestNotification)
+			notifications.GET("", require(model.PermissionNotificationsRead), r.notificationHandler.GetNotificationLogs)
+			notifications.POST("/test", require(model.PermissionNotificationsWrite), r.notificationHandler.TestNotification)
+			notifications.POST("/:id/resend", require(model.PermissionNotificationsWrite), r.notificationHandler.ResendNotification)
+			notifications.POST("/templates/preview", require(model.PermissionNotificationsRead), r.notificationHandler.PreviewTemplate)
+			notifications.GET("/rules", require(model.PermissionNotificationsRead), r.ruleHandler.GetRules)
+			notifications.POST("/rules", require(model.PermissionNotificationsWrite), r.ruleHandler.CreateRule)
+			notifications.GET("/rules/:id", require(model.PermissionNotificationsRead), r.ruleHandler.GetRule)
+			notifications.PUT("/rules/:id", require(model.PermissionNotificationsWrite), r.ruleHandler.UpdateRule)
+			notifications.DELETE("/rules/:id", require(model.PermissionNotificationsWrite), r.ruleHandler.DeleteRule)
+		}
+
+		// 通知订阅
+		subscribers := protected.Group("/subscribers")
+		{
+			subscribers.GET("", require(model.PermissionNotificationsRead), r.subscriptionHandler.GetSubscribers)
+			subscribers.POST("", require(model.PermissionNotificationsWrite), r.subscriptionHandler.CreateSubscriber)
+			subscribers.GET("/:id", require(model.PermissionNotificationsRead), r.subscriptionHandler.GetSubscriber)
+			subscribers.PUT("/:id", require(model.PermissionNotificationsWrite), r.subscriptionHandler.UpdateSubscriber)
+			subscribers.DELETE("/:id", require(model.PermissionNotificationsWrite), r.subscriptionHandler.DeleteSubscriber)
+		}
+
+		// 监控状态
+		monitor := protected.Group("/monitor")
+		{
+			monitor.GET("/status", require(model.PermissionMonitorRead), r.monitorHandler.GetStatus)
 		}
 	}
 
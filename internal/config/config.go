@@ -1,20 +1,25 @@
 package config
 
 import (
+	"time"
+
 	"weex-watchdog/pkg/database"
 	"weex-watchdog/pkg/logger"
 	"weex-watchdog/pkg/notification"
+	"weex-watchdog/pkg/weex"
 )
 
 // Config 应用配置
 type Config struct {
 	Server struct {
-		Port string `mapstructure:"port"`
-		Mode string `mapstructure:"mode"`
+		Port            string        `mapstructure:"port"`
+		Mode            string        `mapstructure:"mode"`
+		ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+		TrustedProxies  []string      `mapstructure:"trusted_proxies"` // 可信反向代理的IP或网段，为空时不信任 X-Forwarded-For
 	} `mapstructure:"server"`
 	Database     database.Config     `mapstructure:"database"`
 	Log          logger.Config       `mapstructure:"log"`
-	Weex         WeexConfig          `mapstructure:"weex"`
+	Weex         weex.Config         `mapstructure:"weex"`
 	Monitor      MonitorConfig       `mapstructure:"monitor"`
 	Notification notification.Config `mapstructure:"notification"`
 	Auth         AuthConfig          `mapstructure:"auth"`
@@ -22,20 +27,36 @@ type Config struct {
 
 // AuthConfig 认证配置
 type AuthConfig struct {
-	Username string `mapstructure:"username"`
-	Password string `mapstructure:"password"`
-	AESKey   string `mapstructure:"aes_key"`
+	Username        string        `mapstructure:"username"` // 初始管理员账号，users 表为空时自动创建
+	Password        string        `mapstructure:"password"`
+	AccessTokenTTL  time.Duration `mapstructure:"access_token_ttl"`  // 访问令牌有效期
+	RefreshTokenTTL time.Duration `mapstructure:"refresh_token_ttl"` // 刷新令牌有效期
+	Lockout         LockoutConfig `mapstructure:"lockout"`
 }
 
-// WeexConfig Weex API配置
-type WeexConfig struct {
-	APIURL     string `mapstructure:"api_url"`
-	Timeout    string `mapstructure:"timeout"`
-	RetryTimes int    `mapstructure:"retry_times"`
+// LockoutConfig 登录失败锁定配置
+// 同一用户名或同一IP在 Window 内连续失败达到上限后锁定，第 n 次锁定时长为 BaseDuration * 2^(n-1)，最长 MaxDuration
+type LockoutConfig struct {
+	MaxAttempts   int           `mapstructure:"max_attempts"`    // 单个用户名允许的连续失败次数
+	IPMaxAttempts int           `mapstructure:"ip_max_attempts"` // 单个IP允许的连续失败次数
+	Window        time.Duration `mapstructure:"window"`          // 距上次失败超过该时长后重新计数
+	BaseDuration  time.Duration `mapstructure:"base_duration"`
+	MaxDuration   time.Duration `mapstructure:"max_duration"`
 }
 
 // MonitorConfig 监控配置
 type MonitorConfig struct {
-	DefaultInterval string `mapstructure:"default_interval"`
-	MaxGoroutines   int    `mapstructure:"max_goroutines"`
+	DefaultInterval      string `mapstructure:"default_interval"`
+	MaxGoroutines        int    `mapstructure:"max_goroutines"`
+	QueueSize            int    `mapstructure:"queue_size"`
+	ReconcileMaxAttempts int    `mapstructure:"reconcile_max_attempts"` // 平仓后在历史订单中查找盈亏的最大尝试次数
+
+	// BaselineHistoryWindow 新交易员建立基线时导入多长时间内的已平仓历史，0 表示不导入
+	BaselineHistoryWindow time.Duration `mapstructure:"baseline_history_window"`
+
+	// 平仓确认：订单连续缺失 CloseConfirmPolls 次且缺失时长超过 CloseGracePeriod 才判定平仓
+	CloseConfirmPolls      int           `mapstructure:"close_confirm_polls"`
+	CloseGracePeriod       time.Duration `mapstructure:"close_grace_period"`
+	SuspiciousMinOrders    int           `mapstructure:"suspicious_min_orders"`    // 持仓数不少于该值时全部同时消失视为可疑，0 表示关闭
+	SuspiciousConfirmPolls int           `mapstructure:"suspicious_confirm_polls"` // 可疑情况下确认平仓需要的连续缺失次数
 }
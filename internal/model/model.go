@@ -9,13 +9,15 @@ import (
 
 // TraderMonitor 监控交易员配置
 type TraderMonitor struct {
-	ID              uint      `json:"id" gorm:"primaryKey"`
-	TraderUserID    string    `json:"trader_user_id" gorm:"type:varchar(50);not null;uniqueIndex"`
-	TraderName      string    `json:"trader_name" gorm:"type:varchar(100)"`
-	IsActive        bool      `json:"is_active" gorm:"default:true;index"`
-	MonitorInterval int       `json:"monitor_interval" gorm:"default:30"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID                    uint       `json:"id" gorm:"primaryKey"`
+	TraderUserID          string     `json:"trader_user_id" gorm:"type:varchar(50);not null;uniqueIndex"`
+	TraderName            string     `json:"trader_name" gorm:"type:varchar(100)"`
+	IsActive              bool       `json:"is_active" gorm:"default:true;index"`
+	MonitorInterval       int        `json:"monitor_interval" gorm:"default:30"`
+	NotifyInitialSnapshot bool       `json:"notify_initial_snapshot" gorm:"default:false"` // 建立基线时是否推送已有持仓
+	BaselineAt            *time.Time `json:"baseline_at"`                                  // 基线建立时间，为空表示尚未完成首次拉取
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
 }
 
 // TableName 指定表名
@@ -73,6 +75,16 @@ type OrderHistory struct {
 	FirstSeenAt    time.Time   `json:"first_seen_at" gorm:"index"`
 	LastSeenAt     time.Time   `json:"last_seen_at"`
 	ClosedAt       *time.Time  `json:"closed_at"`
+
+	// 平仓对账信息，来自历史订单接口
+	AverageClosePrice string          `json:"average_close_price" gorm:"type:varchar(32)"`
+	RealizedPnl       string          `json:"realized_pnl" gorm:"type:varchar(32)"`
+	CloseFee          string          `json:"close_fee" gorm:"type:varchar(32)"`
+	FundingFee        string          `json:"funding_fee" gorm:"type:varchar(32)"`
+	ClosedBy          string          `json:"closed_by" gorm:"type:varchar(30)"`
+	CloseTime         *time.Time      `json:"close_time"`
+	ReconcileStatus   ReconcileStatus `json:"reconcile_status" gorm:"type:varchar(20);index"`
+	ReconcileAttempts int             `json:"reconcile_attempts" gorm:"default:0"`
 }
 
 // TableName 指定表名
@@ -80,21 +92,216 @@ func (OrderHistory) TableName() string {
 	return "order_history"
 }
 
+// ReconcileStatus 平仓对账状态
+type ReconcileStatus string
+
+const (
+	ReconcileStatusPending ReconcileStatus = "PENDING" // 等待历史订单接口出现对应记录
+	ReconcileStatusDone    ReconcileStatus = "DONE"    // 已补全平仓信息
+	ReconcileStatusExpired ReconcileStatus = "EXPIRED" // 超过最大尝试次数仍未找到
+)
+
+// Role 用户角色
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"    // 全部权限，包括用户和 API 密钥管理、审计日志、删除交易员
+	RoleOperator Role = "operator" // 管理交易员、通知和订阅
+	RoleViewer   Role = "viewer"   // 只读订单、统计和分析
+)
+
+// Permission 接口权限
+type Permission string
+
+const (
+	PermissionTradersRead        Permission = "traders:read"
+	PermissionTradersWrite       Permission = "traders:write"
+	PermissionTradersDelete      Permission = "traders:delete" // 删除交易员会同时删除其订单历史
+	PermissionOrdersRead         Permission = "orders:read"
+	PermissionAnalysisRead       Permission = "analysis:read"
+	PermissionNotificationsRead  Permission = "notifications:read"
+	PermissionNotificationsWrite Permission = "notifications:write"
+	PermissionMonitorRead        Permission = "monitor:read"
+	PermissionUsersManage        Permission = "users:manage"
+	PermissionAPIKeysManage      Permission = "api_keys:manage"
+	PermissionAuditRead          Permission = "audit:read"
+)
+
+// rolePermissions 各角色拥有的权限
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {
+		PermissionTradersRead, PermissionTradersWrite, PermissionTradersDelete,
+		PermissionOrdersRead, PermissionAnalysisRead,
+		PermissionNotificationsRead, PermissionNotificationsWrite,
+		PermissionMonitorRead, PermissionUsersManage, PermissionAPIKeysManage,
+		PermissionAuditRead,
+	},
+	RoleOperator: {
+		PermissionTradersRead, PermissionTradersWrite,
+		PermissionOrdersRead, PermissionAnalysisRead,
+		PermissionNotificationsRead, PermissionNotificationsWrite,
+		PermissionMonitorRead,
+	},
+	RoleViewer: {
+		PermissionOrdersRead, PermissionAnalysisRead,
+	},
+}
+
+// Valid 是否为已定义的权限
+func (p Permission) Valid() bool {
+	return RoleAdmin.HasPermission(p)
+}
+
+// Permissions 权限列表，以 JSON 数组存储
+type Permissions []Permission
+
+// Value 实现 driver.Valuer 接口
+func (p Permissions) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return json.Marshal(p)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (p *Permissions) Scan(value interface{}) error {
+	if value == nil {
+		*p = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, p)
+}
+
+// Contains 是否包含指定权限
+func (p Permissions) Contains(permission Permission) bool {
+	for _, item := range p {
+		if item == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Valid 是否为已定义的角色
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// HasPermission 角色是否拥有指定权限
+func (r Role) HasPermission(permission Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions 角色拥有的权限
+func (r Role) Permissions() []Permission {
+	return rolePermissions[r]
+}
+
+// User 系统用户
+type User struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	Username     string     `json:"username" gorm:"type:varchar(50);not null;uniqueIndex"`
+	PasswordHash string     `json:"-" gorm:"type:varchar(100);not null"` // bcrypt 哈希
+	Role         Role       `json:"role" gorm:"type:varchar(20);not null;default:'viewer'"`
+	IsActive     bool       `json:"is_active" gorm:"default:true"`
+	LastLoginAt  *time.Time `json:"last_login_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (User) TableName() string {
+	return "users"
+}
+
+// UserSession 登录会话，只保存令牌的 SHA-256 哈希
+type UserSession struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	UserID           uint       `json:"user_id" gorm:"not null;index"`
+	AccessTokenHash  string     `json:"-" gorm:"type:char(64);not null;uniqueIndex"`
+	RefreshTokenHash string     `json:"-" gorm:"type:char(64);not null;uniqueIndex"`
+	AccessExpiresAt  time.Time  `json:"access_expires_at"`
+	RefreshExpiresAt time.Time  `json:"refresh_expires_at" gorm:"index"`
+	RevokedAt        *time.Time `json:"revoked_at"` // 登出、刷新或禁用用户后失效
+	ClientIP         string     `json:"client_ip" gorm:"type:varchar(64)"`
+	UserAgent        string     `json:"user_agent" gorm:"type:varchar(255)"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
+
+// LoginThrottle 登录失败计数，按用户名和IP分别记录
+type LoginThrottle struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	Key           string     `json:"key" gorm:"type:varchar(120);not null;uniqueIndex"` // user:{用户名} 或 ip:{IP}
+	Failures      int        `json:"failures" gorm:"default:0"`                         // 当前窗口内的尝试次数，包括进行中的尝试
+	Lockouts      int        `json:"lockouts" gorm:"default:0"`                         // 连续锁定次数，决定下次锁定时长
+	LockedUntil   *time.Time `json:"locked_until" gorm:"index"`
+	LastFailureAt time.Time  `json:"last_failure_at" gorm:"index"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (LoginThrottle) TableName() string {
+	return "login_throttles"
+}
+
+// APIKeyPrefix API 密钥的固定前缀，用于区分 API 密钥和登录令牌
+const APIKeyPrefix = "wdk_"
+
+// APIKey 供脚本和看板使用的 API 密钥，只保存密钥的 SHA-256 哈希
+// 使用密钥的请求以创建人的身份执行，权限为密钥范围与创建人角色权限的交集
+type APIKey struct {
+	ID         uint        `json:"id" gorm:"primaryKey"`
+	Name       string      `json:"name" gorm:"type:varchar(100);not null"`
+	Prefix     string      `json:"prefix" gorm:"type:varchar(16);not null"` // 密钥开头几位，用于识别密钥
+	KeyHash    string      `json:"-" gorm:"type:char(64);not null;uniqueIndex"`
+	Scopes     Permissions `json:"scopes" gorm:"type:json"`
+	UserID     uint        `json:"user_id" gorm:"not null;index"` // 创建人
+	ExpiresAt  *time.Time  `json:"expires_at"`                    // 为空时不过期
+	LastUsedAt *time.Time  `json:"last_used_at"`
+	RevokedAt  *time.Time  `json:"revoked_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// TableName 指定表名
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
 // NotificationType 通知类型枚举
 type NotificationType string
 
 const (
-	NotificationTypeNewOrder    NotificationType = "NEW_ORDER"
-	NotificationTypeOrderClosed NotificationType = "ORDER_CLOSED"
+	NotificationTypeNewOrder     NotificationType = "NEW_ORDER"
+	NotificationTypeOrderClosed  NotificationType = "ORDER_CLOSED"
+	NotificationTypeOrderUpdated NotificationType = "ORDER_UPDATED"
 )
 
 // NotificationStatus 通知状态枚举
 type NotificationStatus string
 
 const (
-	NotificationStatusPending NotificationStatus = "PENDING"
-	NotificationStatusSuccess NotificationStatus = "SUCCESS"
-	NotificationStatusFailed  NotificationStatus = "FAILED"
+	NotificationStatusPending  NotificationStatus = "PENDING"
+	NotificationStatusSuccess  NotificationStatus = "SUCCESS"
+	NotificationStatusFailed   NotificationStatus = "FAILED"
+	NotificationStatusDead     NotificationStatus = "DEAD"     // 超过最大重试次数，不再自动重试
+	NotificationStatusFiltered NotificationStatus = "FILTERED" // 被过滤规则拦截，未发送
 )
 
 // NotificationLog 通知记录
@@ -102,14 +309,286 @@ type NotificationLog struct {
 	ID               uint               `json:"id" gorm:"primaryKey"`
 	TraderUserID     string             `json:"trader_user_id" gorm:"type:varchar(50);not null;index"`
 	OrderID          string             `json:"order_id" gorm:"type:varchar(50)"`
-	NotificationType NotificationType   `json:"notification_type" gorm:"type:enum('NEW_ORDER','ORDER_CLOSED');not null;index"`
-	Message          string             `json:"message" gorm:"type:text"`
-	Status           NotificationStatus `json:"status" gorm:"type:enum('PENDING','SUCCESS','FAILED');default:'PENDING';index"`
+	Channel          string             `json:"channel" gorm:"type:varchar(30);index"` // 发送渠道
+	NotificationType NotificationType   `json:"notification_type" gorm:"type:varchar(30);not null;index"`
+	SubscriberID     *uint              `json:"subscriber_id" gorm:"index"`             // 订阅人，为空表示渠道默认接收人
+	Recipient        string             `json:"recipient" gorm:"type:varchar(255)"`     // 接收地址
+	BatchID          string             `json:"batch_id" gorm:"type:varchar(32);index"` // 同一次发送的通知共用，重试时整批重发
+	Message          string             `json:"message" gorm:"type:mediumtext"`         // 渲染后的消息内容，重试时原样发送
+	Status           NotificationStatus `json:"status" gorm:"type:varchar(20);default:'PENDING';index"`
+	Attempts         int                `json:"attempts" gorm:"default:0"`  // 已发送次数
+	NextRetryAt      *time.Time         `json:"next_retry_at" gorm:"index"` // 下次重试时间
 	SentAt           time.Time          `json:"sent_at"`
 	ErrorMsg         string             `json:"error_msg" gorm:"type:text"`
+	Delivered        DeliveredParts     `json:"delivered" gorm:"type:json"` // 部分发送失败时已送达的接收地址和分段，重试时跳过
 }
 
 // TableName 指定表名
 func (NotificationLog) TableName() string {
 	return "notification_logs"
 }
+
+// DeliveredParts 已送达的接收地址和分段标识，以 JSON 数组存储
+type DeliveredParts []string
+
+// Value 实现 driver.Valuer 接口
+func (p DeliveredParts) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return json.Marshal(p)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (p *DeliveredParts) Scan(value interface{}) error {
+	if value == nil {
+		*p = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, p)
+}
+
+// NotificationTypes 事件类型列表，以 JSON 数组存储
+type NotificationTypes []NotificationType
+
+// Value 实现 driver.Valuer 接口
+func (t NotificationTypes) Value() (driver.Value, error) {
+	if t == nil {
+		return nil, nil
+	}
+	return json.Marshal(t)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (t *NotificationTypes) Scan(value interface{}) error {
+	if value == nil {
+		*t = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, t)
+}
+
+// Contains 是否包含事件类型，列表为空时包含全部事件
+func (t NotificationTypes) Contains(notificationType NotificationType) bool {
+	if len(t) == 0 {
+		return true
+	}
+	for _, item := range t {
+		if item == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscriber 通知订阅人，通过一个渠道地址接收所订阅交易员的通知
+type Subscriber struct {
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	Name          string         `json:"name" gorm:"type:varchar(100);not null"`
+	Channel       string         `json:"channel" gorm:"type:varchar(30);not null;index"` // 接收渠道
+	Address       string         `json:"address" gorm:"type:varchar(255);not null"`      // 渠道内的接收地址，如 WxPusher UID、企业微信账号、Telegram 会话ID、邮箱、webhook 地址
+	IsActive      bool           `json:"is_active" gorm:"default:true;index"`
+	Subscriptions []Subscription `json:"subscriptions" gorm:"foreignKey:SubscriberID"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (Subscriber) TableName() string {
+	return "subscribers"
+}
+
+// Subscription 订阅人关注的交易员及事件类型
+type Subscription struct {
+	ID           uint              `json:"id" gorm:"primaryKey"`
+	SubscriberID uint              `json:"subscriber_id" gorm:"not null;uniqueIndex:uk_subscriber_trader"`
+	TraderUserID string            `json:"trader_user_id" gorm:"type:varchar(50);not null;uniqueIndex:uk_subscriber_trader;index"`
+	EventTypes   NotificationTypes `json:"event_types" gorm:"type:json"` // 为空时接收全部事件
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// TableName 指定表名
+func (Subscription) TableName() string {
+	return "subscriptions"
+}
+
+// RuleAction 过滤规则动作
+type RuleAction string
+
+const (
+	RuleActionInclude RuleAction = "INCLUDE" // 只通知匹配的订单
+	RuleActionExclude RuleAction = "EXCLUDE" // 不通知匹配的订单
+)
+
+// NotificationRule 通知过滤规则，所有已设置的条件同时满足时视为匹配
+type NotificationRule struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	Name           string     `json:"name" gorm:"type:varchar(100);not null"`
+	TraderUserID   string     `json:"trader_user_id" gorm:"type:varchar(50);index"` // 为空时对所有交易员生效
+	Action         RuleAction `json:"action" gorm:"type:varchar(10);not null"`
+	ContractSymbol string     `json:"contract_symbol" gorm:"type:varchar(50)"` // 如 BTC/USDT，也可以只写 BTC
+	PositionSide   string     `json:"position_side" gorm:"type:varchar(10)"`   // LONG / SHORT
+	MinLeverage    float64    `json:"min_leverage"`                            // 杠杆不低于该值
+	MinNotional    float64    `json:"min_notional"`                            // 名义价值（开仓数量 × 开仓价）不低于该值
+	QuietStart     string     `json:"quiet_start" gorm:"type:varchar(5)"`      // 时段开始 HH:MM，可跨零点
+	QuietEnd       string     `json:"quiet_end" gorm:"type:varchar(5)"`        // 时段结束 HH:MM
+	IsActive       bool       `json:"is_active" gorm:"default:true;index"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (NotificationRule) TableName() string {
+	return "notification_rules"
+}
+
+// OrderChangeType 持仓变化类型
+type OrderChangeType string
+
+const (
+	OrderChangeTakeProfitAdded   OrderChangeType = "TP_ADDED"
+	OrderChangeTakeProfitMoved   OrderChangeType = "TP_MOVED"
+	OrderChangeTakeProfitRemoved OrderChangeType = "TP_REMOVED"
+	OrderChangeStopLossAdded     OrderChangeType = "SL_ADDED"
+	OrderChangeStopLossMoved     OrderChangeType = "SL_MOVED"
+	OrderChangeStopLossRemoved   OrderChangeType = "SL_REMOVED"
+	OrderChangeScaledIn          OrderChangeType = "SCALED_IN"
+	OrderChangePartialClose      OrderChangeType = "PARTIAL_CLOSE"
+	OrderChangeMarginChanged     OrderChangeType = "MARGIN_CHANGED"
+	OrderChangeLiquidationMoved  OrderChangeType = "LIQ_PRICE_MOVED"
+)
+
+// Label 变化类型的中文描述
+func (t OrderChangeType) Label() string {
+	switch t {
+	case OrderChangeTakeProfitAdded:
+		return "设置止盈"
+	case OrderChangeTakeProfitMoved:
+		return "止盈调整"
+	case OrderChangeTakeProfitRemoved:
+		return "取消止盈"
+	case OrderChangeStopLossAdded:
+		return "设置止损"
+	case OrderChangeStopLossMoved:
+		return "止损调整"
+	case OrderChangeStopLossRemoved:
+		return "取消止损"
+	case OrderChangeScaledIn:
+		return "加仓"
+	case OrderChangePartialClose:
+		return "部分平仓"
+	case OrderChangeMarginChanged:
+		return "保证金变动"
+	case OrderChangeLiquidationMoved:
+		return "强平价变动"
+	default:
+		return string(t)
+	}
+}
+
+// OrderChangeEvent 持仓变化事件
+type OrderChangeEvent struct {
+	ID             uint            `json:"id" gorm:"primaryKey"`
+	TraderUserID   string          `json:"trader_user_id" gorm:"type:varchar(50);not null;index"`
+	TraderName     string          `json:"trader_name" gorm:"type:varchar(100)"`
+	OrderID        string          `json:"order_id" gorm:"type:varchar(50);not null;index"`
+	ContractSymbol string          `json:"contract_symbol" gorm:"type:varchar(50)"`
+	PositionSide   string          `json:"position_side" gorm:"type:varchar(10)"`
+	ChangeType     OrderChangeType `json:"change_type" gorm:"type:varchar(30);not null;index"`
+	OldValue       string          `json:"old_value" gorm:"type:varchar(50)"`
+	NewValue       string          `json:"new_value" gorm:"type:varchar(50)"`
+	DetectedAt     time.Time       `json:"detected_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (OrderChangeEvent) TableName() string {
+	return "order_change_events"
+}
+
+// 审计操作类型
+const (
+	AuditActionTraderCreate       = "trader.create"
+	AuditActionTraderUpdate       = "trader.update"
+	AuditActionTraderDelete       = "trader.delete" // 同时删除交易员的订单历史
+	AuditActionTraderToggle       = "trader.toggle"
+	AuditActionNotificationTest   = "notification.test"
+	AuditActionNotificationResend = "notification.resend"
+	AuditActionRuleCreate         = "notification_rule.create"
+	AuditActionRuleUpdate         = "notification_rule.update"
+	AuditActionRuleDelete         = "notification_rule.delete"
+	AuditActionSubscriberCreate   = "subscriber.create"
+	AuditActionSubscriberUpdate   = "subscriber.update"
+	AuditActionSubscriberDelete   = "subscriber.delete"
+	AuditActionLogin              = "auth.login"
+	AuditActionLoginFailed        = "auth.login_failed"
+	AuditActionLogout             = "auth.logout"
+	AuditActionPasswordChange     = "auth.password_change"
+	AuditActionUserCreate         = "user.create"
+	AuditActionUserUpdate         = "user.update"
+	AuditActionUserDelete         = "user.delete"
+	AuditActionAPIKeyCreate       = "api_key.create"
+	AuditActionAPIKeyRevoke       = "api_key.revoke"
+)
+
+// AuditChange 字段修改前后的值
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditChanges 字段名到修改前后值的映射，以 JSON 对象存储
+type AuditChanges map[string]AuditChange
+
+// Value 实现 driver.Valuer 接口
+func (c AuditChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (c *AuditChanges) Scan(value interface{}) error {
+	if value == nil {
+		*c = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, c)
+}
+
+// AuditLog 管理操作审计日志
+type AuditLog struct {
+	ID         uint         `json:"id" gorm:"primaryKey"`
+	ActorID    *uint        `json:"actor_id" gorm:"index"`                         // 操作用户，登录失败时为空
+	ActorName  string       `json:"actor_name" gorm:"type:varchar(50);index"`      // 操作用户名，登录失败时为尝试的用户名
+	APIKeyID   *uint        `json:"api_key_id"`                                    // 通过 API 密钥操作时的密钥
+	Action     string       `json:"action" gorm:"type:varchar(50);not null;index"` // 如 trader.delete
+	TargetType string       `json:"target_type" gorm:"type:varchar(30);index:idx_audit_target"`
+	TargetID   string       `json:"target_id" gorm:"type:varchar(64);index:idx_audit_target"`
+	Changes    AuditChanges `json:"changes" gorm:"type:json"` // 修改前后有差异的字段
+	ClientIP   string       `json:"client_ip" gorm:"type:varchar(64)"`
+	CreatedAt  time.Time    `json:"created_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"time"
+
+	"weex-watchdog/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyRepository API 密钥仓库实现
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository 创建 API 密钥仓库
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(key *model.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *apiKeyRepository) GetByID(id uint) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.First(&key, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetByHash(hash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.Where("key_hash = ?", hash).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetAll(offset, limit int) ([]model.APIKey, int64, error) {
+	var keys []model.APIKey
+	var count int64
+
+	err := r.db.Model(&model.APIKey{}).Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = r.db.Order("id DESC").Offset(offset).Limit(limit).Find(&keys).Error
+	return keys, count, err
+}
+
+// Revoke 吊销密钥，已吊销的密钥保持原吊销时间
+func (r *apiKeyRepository) Revoke(id uint) error {
+	return r.db.Model(&model.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *apiKeyRepository) UpdateLastUsed(id uint, at time.Time) error {
+	return r.db.Model(&model.APIKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
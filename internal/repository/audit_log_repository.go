@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"time"
+
+	"weex-watchdog/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// auditLogRepository 审计日志仓库实现
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepository 创建审计日志仓库
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+func (r *auditLogRepository) Create(log *model.AuditLog) error {
+	return r.db.Create(log).Error
+}
+
+func (r *auditLogRepository) GetLogs(filters map[string]interface{}, offset, limit int) ([]model.AuditLog, int64, error) {
+	var logs []model.AuditLog
+	var count int64
+
+	query := r.db.Model(&model.AuditLog{})
+
+	// 操作人筛选
+	if actor, ok := filters["actor"].(string); ok && actor != "" {
+		query = query.Where("actor_name = ?", actor)
+	}
+
+	// 操作类型筛选，以 . 结尾时按前缀匹配，如 trader.
+	if action, ok := filters["action"].(string); ok && action != "" {
+		if action[len(action)-1] == '.' {
+			query = query.Where("action LIKE ?", action+"%")
+		} else {
+			query = query.Where("action = ?", action)
+		}
+	}
+
+	// 操作对象筛选
+	if targetType, ok := filters["target_type"].(string); ok && targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if targetID, ok := filters["target_id"].(string); ok && targetID != "" {
+		query = query.Where("target_id = ?", targetID)
+	}
+
+	// 时间范围筛选
+	if start, ok := filters["start_time"].(time.Time); ok {
+		query = query.Where("created_at >= ?", start)
+	}
+	if end, ok := filters["end_time"].(time.Time); ok {
+		query = query.Where("created_at < ?", end)
+	}
+
+	err := query.Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = query.Order("id DESC").Offset(offset).Limit(limit).Find(&logs).Error
+	return logs, count, err
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"time"
+
+	"weex-watchdog/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// loginThrottleRepository 登录失败计数仓库实现
+type loginThrottleRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginThrottleRepository 创建登录失败计数仓库
+func NewLoginThrottleRepository(db *gorm.DB) LoginThrottleRepository {
+	return &loginThrottleRepository{db: db}
+}
+
+func (r *loginThrottleRepository) GetByKeys(keys []string) ([]model.LoginThrottle, error) {
+	var throttles []model.LoginThrottle
+	err := r.db.Where("`key` IN ?", keys).Find(&throttles).Error
+	return throttles, err
+}
+
+func (r *loginThrottleRepository) Save(throttle *model.LoginThrottle) error {
+	return r.db.Save(throttle).Error
+}
+
+func (r *loginThrottleRepository) DeleteByKey(key string) error {
+	return r.db.Where("`key` = ?", key).Delete(&model.LoginThrottle{}).Error
+}
+
+// DeleteStale 删除未锁定且最后一次失败早于 before 的记录
+func (r *loginThrottleRepository) DeleteStale(before time.Time) (int64, error) {
+	result := r.db.
+		Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)", before, time.Now()).
+		Delete(&model.LoginThrottle{})
+	return result.RowsAffected, result.Error
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"weex-watchdog/internal/model"
 
 	"gorm.io/gorm"
@@ -40,7 +42,46 @@ func (r *notificationRepository) UpdateStatusBatch(ids []uint, status model.Noti
 	return r.db.Model(&model.NotificationLog{}).Where("id IN ?", ids).Updates(updates).Error
 }
 
-func (r *notificationRepository) GetLogs(traderUserID string, offset, limit int) ([]model.NotificationLog, int64, error) {
+// RecordAttempt 记录一次发送结果，成功时清空错误信息并更新发送时间，delivered 为已送达的部分
+func (r *notificationRepository) RecordAttempt(ids []uint, status model.NotificationStatus, attempts int, nextRetryAt *time.Time, errorMsg string, delivered model.DeliveredParts) error {
+	updates := map[string]interface{}{
+		"status":        status,
+		"attempts":      attempts,
+		"next_retry_at": nextRetryAt,
+		"error_msg":     errorMsg,
+		"delivered":     delivered,
+	}
+	if status == model.NotificationStatusSuccess {
+		updates["sent_at"] = time.Now()
+	}
+	return r.db.Model(&model.NotificationLog{}).Where("id IN ?", ids).Updates(updates).Error
+}
+
+func (r *notificationRepository) GetByID(id uint) (*model.NotificationLog, error) {
+	var log model.NotificationLog
+	err := r.db.First(&log, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+func (r *notificationRepository) GetByBatchID(batchID string) ([]model.NotificationLog, error) {
+	var logs []model.NotificationLog
+	err := r.db.Where("batch_id = ?", batchID).Order("id ASC").Find(&logs).Error
+	return logs, err
+}
+
+// GetDueRetries 获取到达重试时间的待发送和失败通知，没有保存消息内容的旧记录不会重试
+func (r *notificationRepository) GetDueRetries(now time.Time, limit int) ([]model.NotificationLog, error) {
+	var logs []model.NotificationLog
+	err := r.db.Where("status IN ? AND next_retry_at <= ? AND message <> ''",
+		[]model.NotificationStatus{model.NotificationStatusPending, model.NotificationStatusFailed}, now).
+		Order("next_retry_at ASC").Limit(limit).Find(&logs).Error
+	return logs, err
+}
+
+func (r *notificationRepository) GetLogs(traderUserID string, filters map[string]interface{}, offset, limit int) ([]model.NotificationLog, int64, error) {
 	var logs []model.NotificationLog
 	var count int64
 
@@ -49,6 +90,21 @@ func (r *notificationRepository) GetLogs(traderUserID string, offset, limit int)
 		query = query.Where("trader_user_id = ?", traderUserID)
 	}
 
+	// 状态筛选
+	if status, ok := filters["status"].(string); ok && status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	// 渠道筛选
+	if channel, ok := filters["channel"].(string); ok && channel != "" {
+		query = query.Where("channel = ?", channel)
+	}
+
+	// 订阅人筛选
+	if subscriberID, ok := filters["subscriber_id"].(uint); ok && subscriberID > 0 {
+		query = query.Where("subscriber_id = ?", subscriberID)
+	}
+
 	err := query.Count(&count).Error
 	if err != nil {
 		return nil, 0, err
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"weex-watchdog/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// notificationRuleRepository 通知过滤规则仓库实现
+type notificationRuleRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRuleRepository 创建通知过滤规则仓库
+func NewNotificationRuleRepository(db *gorm.DB) NotificationRuleRepository {
+	return &notificationRuleRepository{db: db}
+}
+
+func (r *notificationRuleRepository) Create(rule *model.NotificationRule) error {
+	if err := r.db.Create(rule).Error; err != nil {
+		return err
+	}
+	// is_active 有默认值，创建时 false 会被忽略，需要单独更新
+	if !rule.IsActive {
+		return r.db.Model(rule).Update("is_active", false).Error
+	}
+	return nil
+}
+
+func (r *notificationRuleRepository) GetByID(id uint) (*model.NotificationRule, error) {
+	var rule model.NotificationRule
+	err := r.db.First(&rule, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// GetAll 获取规则列表，traderUserID 不为空时只返回该交易员的规则和全局规则
+func (r *notificationRuleRepository) GetAll(traderUserID string) ([]model.NotificationRule, error) {
+	var rules []model.NotificationRule
+	query := r.db.Model(&model.NotificationRule{})
+	if traderUserID != "" {
+		query = query.Where("trader_user_id = ? OR trader_user_id = ''", traderUserID)
+	}
+	err := query.Order("id ASC").Find(&rules).Error
+	return rules, err
+}
+
+// GetActiveRules 获取对交易员生效的规则（全局规则和该交易员的规则）
+func (r *notificationRuleRepository) GetActiveRules(traderUserID string) ([]model.NotificationRule, error) {
+	var rules []model.NotificationRule
+	err := r.db.Where("is_active = ? AND (trader_user_id = ? OR trader_user_id = '')", true, traderUserID).
+		Order("id ASC").Find(&rules).Error
+	return rules, err
+}
+
+func (r *notificationRuleRepository) Update(rule *model.NotificationRule) error {
+	return r.db.Save(rule).Error
+}
+
+func (r *notificationRuleRepository) Delete(id uint) error {
+	return r.db.Delete(&model.NotificationRule{}, id).Error
+}
@@ -46,6 +46,51 @@ func (r *orderRepository) UpdateOrderStatus(id uint, status model.OrderStatus, c
 	return r.db.Model(&model.OrderHistory{}).Where("id = ?", id).Updates(updates).Error
 }
 
+// UpdateSnapshot 更新订单快照及随持仓变化的字段
+func (r *orderRepository) UpdateSnapshot(order *model.OrderHistory) error {
+	return r.db.Model(&model.OrderHistory{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+		"order_data":   order.OrderData,
+		"open_size":    order.OpenSize,
+		"open_price":   order.OpenPrice,
+		"last_seen_at": order.LastSeenAt,
+	}).Error
+}
+
+// UpdateCloseDetails 更新平仓对账信息
+func (r *orderRepository) UpdateCloseDetails(order *model.OrderHistory) error {
+	return r.db.Model(&model.OrderHistory{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+		"average_close_price": order.AverageClosePrice,
+		"realized_pnl":        order.RealizedPnl,
+		"close_fee":           order.CloseFee,
+		"funding_fee":         order.FundingFee,
+		"closed_by":           order.ClosedBy,
+		"close_time":          order.CloseTime,
+		"reconcile_status":    order.ReconcileStatus,
+		"reconcile_attempts":  order.ReconcileAttempts,
+	}).Error
+}
+
+// GetPendingReconcileOrders 获取等待平仓对账的订单
+func (r *orderRepository) GetPendingReconcileOrders(traderUserID string) ([]model.OrderHistory, error) {
+	var orders []model.OrderHistory
+	err := r.db.Where("trader_user_id = ? AND status = ? AND reconcile_status = ?",
+		traderUserID, model.OrderStatusClosed, model.ReconcileStatusPending).Find(&orders).Error
+	return orders, err
+}
+
+// TouchLastSeen 批量刷新订单最后出现时间
+func (r *orderRepository) TouchLastSeen(ids []uint, lastSeenAt time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&model.OrderHistory{}).Where("id IN ?", ids).Update("last_seen_at", lastSeenAt).Error
+}
+
+// CreateChangeEvent 保存持仓变化事件
+func (r *orderRepository) CreateChangeEvent(event *model.OrderChangeEvent) error {
+	return r.db.Create(event).Error
+}
+
 func (r *orderRepository) GetOrderHistory(traderUserID string, offset, limit int) ([]model.OrderHistory, int64, error) {
 	var orders []model.OrderHistory
 	var count int64
@@ -153,6 +198,15 @@ func (r *orderRepository) GetStatistics(traderUserID string) (map[string]interfa
 	return stats, nil
 }
 
+// GetActivityBetween 获取时间段内开仓或平仓的订单，用于汇总通知
+func (r *orderRepository) GetActivityBetween(from, to time.Time) ([]model.OrderHistory, error) {
+	var orders []model.OrderHistory
+	err := r.db.Where("(first_seen_at >= ? AND first_seen_at < ?) OR (closed_at >= ? AND closed_at < ?)", from, to, from, to).
+		Order("trader_user_id, first_seen_at").
+		Find(&orders).Error
+	return orders, err
+}
+
 // DeleteByTraderID 删除指定交易员的所有订单
 func (r *orderRepository) DeleteByTraderID(traderID uint) error {
 	return r.db.Where("trader_user_id = ?", traderID).Delete(&model.OrderHistory{}).Error
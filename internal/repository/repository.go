@@ -16,6 +16,7 @@ type TraderRepository interface {
 	Update(trader *model.TraderMonitor) error
 	Delete(id uint) error
 	ToggleActive(id uint, isActive bool) error
+	MarkBaselineDone(id uint, baselineAt time.Time) error
 }
 
 // OrderRepository 订单仓库接口
@@ -24,9 +25,15 @@ type OrderRepository interface {
 	GetByTraderAndOrderID(traderUserID, orderID string) (*model.OrderHistory, error)
 	GetActiveOrdersByTrader(traderUserID string) ([]model.OrderHistory, error)
 	UpdateOrderStatus(id uint, status model.OrderStatus, closedAt *time.Time) error
+	UpdateSnapshot(order *model.OrderHistory) error
+	UpdateCloseDetails(order *model.OrderHistory) error
+	GetPendingReconcileOrders(traderUserID string) ([]model.OrderHistory, error)
+	TouchLastSeen(ids []uint, lastSeenAt time.Time) error
+	CreateChangeEvent(event *model.OrderChangeEvent) error
 	GetOrderHistory(traderUserID string, offset, limit int) ([]model.OrderHistory, int64, error)
 	GetOrderHistoryWithFilters(traderUserID string, filters map[string]interface{}, offset, limit int) ([]model.OrderHistory, int64, error)
 	GetStatistics(traderUserID string) (map[string]interface{}, error)
+	GetActivityBetween(from, to time.Time) ([]model.OrderHistory, error)
 	DeleteByTraderID(traderID uint) error
 }
 
@@ -35,5 +42,76 @@ type NotificationRepository interface {
 	Create(log *model.NotificationLog) error
 	UpdateStatus(id uint, status model.NotificationStatus, errorMsg string) error
 	UpdateStatusBatch(ids []uint, status model.NotificationStatus, errorMsg string) error
-	GetLogs(traderUserID string, offset, limit int) ([]model.NotificationLog, int64, error)
-}
\ No newline at end of file
+	RecordAttempt(ids []uint, status model.NotificationStatus, attempts int, nextRetryAt *time.Time, errorMsg string, delivered model.DeliveredParts) error
+	GetByID(id uint) (*model.NotificationLog, error)
+	GetByBatchID(batchID string) ([]model.NotificationLog, error)
+	GetDueRetries(now time.Time, limit int) ([]model.NotificationLog, error)
+	GetLogs(traderUserID string, filters map[string]interface{}, offset, limit int) ([]model.NotificationLog, int64, error)
+}
+
+// SubscriberRepository 通知订阅人仓库接口
+type SubscriberRepository interface {
+	Create(subscriber *model.Subscriber) error
+	GetByID(id uint) (*model.Subscriber, error)
+	GetAll(offset, limit int) ([]model.Subscriber, int64, error)
+	GetByTrader(traderUserID string) ([]model.Subscriber, error)
+	Update(subscriber *model.Subscriber) error
+	Delete(id uint) error
+}
+
+// NotificationRuleRepository 通知过滤规则仓库接口
+type NotificationRuleRepository interface {
+	Create(rule *model.NotificationRule) error
+	GetByID(id uint) (*model.NotificationRule, error)
+	GetAll(traderUserID string) ([]model.NotificationRule, error)
+	GetActiveRules(traderUserID string) ([]model.NotificationRule, error)
+	Update(rule *model.NotificationRule) error
+	Delete(id uint) error
+}
+
+// UserRepository 用户仓库接口
+type UserRepository interface {
+	Create(user *model.User) error
+	GetByID(id uint) (*model.User, error)
+	GetByUsername(username string) (*model.User, error)
+	GetAll(offset, limit int) ([]model.User, int64, error)
+	Count() (int64, error)
+	CountByRole(role model.Role) (int64, error)
+	Update(user *model.User) error
+	Delete(id uint) error
+	UpdateLastLogin(id uint, at time.Time) error
+}
+
+// SessionRepository 登录会话仓库接口
+type SessionRepository interface {
+	Create(session *model.UserSession) error
+	GetByAccessTokenHash(hash string) (*model.UserSession, error)
+	GetByRefreshTokenHash(hash string) (*model.UserSession, error)
+	Revoke(id uint) (bool, error)
+	RevokeByUser(userID uint) error
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+// APIKeyRepository API 密钥仓库接口
+type APIKeyRepository interface {
+	Create(key *model.APIKey) error
+	GetByID(id uint) (*model.APIKey, error)
+	GetByHash(hash string) (*model.APIKey, error)
+	GetAll(offset, limit int) ([]model.APIKey, int64, error)
+	Revoke(id uint) error
+	UpdateLastUsed(id uint, at time.Time) error
+}
+
+// AuditLogRepository 审计日志仓库接口
+type AuditLogRepository interface {
+	Create(log *model.AuditLog) error
+	GetLogs(filters map[string]interface{}, offset, limit int) ([]model.AuditLog, int64, error)
+}
+
+// LoginThrottleRepository 登录失败计数仓库接口
+type LoginThrottleRepository interface {
+	GetByKeys(keys []string) ([]model.LoginThrottle, error)
+	Save(throttle *model.LoginThrottle) error
+	DeleteByKey(key string) error
+	DeleteStale(before time.Time) (int64, error)
+}
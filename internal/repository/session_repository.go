@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"time"
+
+	"weex-watchdog/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// sessionRepository 登录会话仓库实现
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository 创建登录会话仓库
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(session *model.UserSession) error {
+	return r.db.Create(session).Error
+}
+
+func (r *sessionRepository) GetByAccessTokenHash(hash string) (*model.UserSession, error) {
+	var session model.UserSession
+	err := r.db.Where("access_token_hash = ?", hash).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) GetByRefreshTokenHash(hash string) (*model.UserSession, error) {
+	var session model.UserSession
+	err := r.db.Where("refresh_token_hash = ?", hash).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Revoke 使会话失效，返回本次调用是否使其失效（已失效的会话返回 false）
+func (r *sessionRepository) Revoke(id uint) (bool, error) {
+	result := r.db.Model(&model.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// RevokeByUser 使用户的所有会话失效
+func (r *sessionRepository) RevokeByUser(userID uint) error {
+	return r.db.Model(&model.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// DeleteExpired 删除刷新令牌已过期的会话
+func (r *sessionRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Where("refresh_expires_at < ?", before).Delete(&model.UserSession{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"weex-watchdog/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// subscriberRepository 通知订阅人仓库实现
+type subscriberRepository struct {
+	db *gorm.DB
+}
+
+// NewSubscriberRepository 创建通知订阅人仓库
+func NewSubscriberRepository(db *gorm.DB) SubscriberRepository {
+	return &subscriberRepository{db: db}
+}
+
+func (r *subscriberRepository) Create(subscriber *model.Subscriber) error {
+	if err := r.db.Create(subscriber).Error; err != nil {
+		return err
+	}
+	// is_active 有默认值，创建时 false 会被忽略，需要单独更新
+	if !subscriber.IsActive {
+		return r.db.Model(subscriber).Update("is_active", false).Error
+	}
+	return nil
+}
+
+func (r *subscriberRepository) GetByID(id uint) (*model.Subscriber, error) {
+	var subscriber model.Subscriber
+	err := r.db.Preload("Subscriptions").First(&subscriber, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &subscriber, nil
+}
+
+func (r *subscriberRepository) GetAll(offset, limit int) ([]model.Subscriber, int64, error) {
+	var subscribers []model.Subscriber
+	var count int64
+
+	err := r.db.Model(&model.Subscriber{}).Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = r.db.Preload("Subscriptions").Order("id ASC").Offset(offset).Limit(limit).Find(&subscribers).Error
+	return subscribers, count, err
+}
+
+// GetByTrader 获取订阅了交易员的所有订阅人（包括已停用的），只加载该交易员的订阅
+func (r *subscriberRepository) GetByTrader(traderUserID string) ([]model.Subscriber, error) {
+	var subscribers []model.Subscriber
+	subQuery := r.db.Model(&model.Subscription{}).Select("subscriber_id").Where("trader_user_id = ?", traderUserID)
+	err := r.db.Where("id IN (?)", subQuery).
+		Preload("Subscriptions", "trader_user_id = ?", traderUserID).
+		Find(&subscribers).Error
+	return subscribers, err
+}
+
+// Update 更新订阅人并整体替换其订阅
+func (r *subscriberRepository) Update(subscriber *model.Subscriber) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Subscriptions").Save(subscriber).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("subscriber_id = ?", subscriber.ID).Delete(&model.Subscription{}).Error; err != nil {
+			return err
+		}
+		for i := range subscriber.Subscriptions {
+			subscriber.Subscriptions[i].ID = 0
+			subscriber.Subscriptions[i].SubscriberID = subscriber.ID
+		}
+		if len(subscriber.Subscriptions) > 0 {
+			return tx.Create(&subscriber.Subscriptions).Error
+		}
+		return nil
+	})
+}
+
+func (r *subscriberRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscriber_id = ?", id).Delete(&model.Subscription{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Subscriber{}, id).Error
+	})
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"time"
 	"weex-watchdog/internal/model"
 
 	"gorm.io/gorm"
@@ -68,3 +69,7 @@ func (r *traderRepository) Delete(id uint) error {
 func (r *traderRepository) ToggleActive(id uint, isActive bool) error {
 	return r.db.Model(&model.TraderMonitor{}).Where("id = ?", id).Update("is_active", isActive).Error
 }
+
+func (r *traderRepository) MarkBaselineDone(id uint, baselineAt time.Time) error {
+	return r.db.Model(&model.TraderMonitor{}).Where("id = ?", id).Update("baseline_at", baselineAt).Error
+}
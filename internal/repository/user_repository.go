@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"time"
+
+	"weex-watchdog/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// userRepository 用户仓库实现
+type userRepository struct {
+	db *gorm.DB
+}
+
+// NewUserRepository 创建用户仓库
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+func (r *userRepository) Create(user *model.User) error {
+	if err := r.db.Create(user).Error; err != nil {
+		return err
+	}
+	// is_active 有默认值，创建时 false 会被忽略，需要单独更新
+	if !user.IsActive {
+		return r.db.Model(user).Update("is_active", false).Error
+	}
+	return nil
+}
+
+func (r *userRepository) GetByID(id uint) (*model.User, error) {
+	var user model.User
+	err := r.db.First(&user, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) GetByUsername(username string) (*model.User, error) {
+	var user model.User
+	err := r.db.Where("username = ?", username).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) GetAll(offset, limit int) ([]model.User, int64, error) {
+	var users []model.User
+	var count int64
+
+	err := r.db.Model(&model.User{}).Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = r.db.Order("id ASC").Offset(offset).Limit(limit).Find(&users).Error
+	return users, count, err
+}
+
+func (r *userRepository) Count() (int64, error) {
+	var count int64
+	err := r.db.Model(&model.User{}).Count(&count).Error
+	return count, err
+}
+
+func (r *userRepository) CountByRole(role model.Role) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.User{}).Where("role = ?", role).Count(&count).Error
+	return count, err
+}
+
+func (r *userRepository) Update(user *model.User) error {
+	return r.db.Save(user).Error
+}
+
+func (r *userRepository) Delete(id uint) error {
+	return r.db.Delete(&model.User{}, id).Error
+}
+
+func (r *userRepository) UpdateLastLogin(id uint, at time.Time) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Update("last_login_at", at).Error
+}
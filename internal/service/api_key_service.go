@@ -0,0 +1,145 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"weex-watchdog/internal/model"
+	"weex-watchdog/internal/repository"
+	"weex-watchdog/pkg/crypto"
+	"weex-watchdog/pkg/logger"
+)
+
+// ErrInvalidAPIKey API 密钥参数不合法
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// apiKeyDisplayLength 保存用于识别密钥的前几位长度（含前缀）
+const apiKeyDisplayLength = 12
+
+// apiKeyLastUsedInterval 最近使用时间的更新间隔，避免每个请求都写数据库
+const apiKeyLastUsedInterval = time.Minute
+
+// APIKeyService API 密钥服务
+type APIKeyService struct {
+	keyRepo  repository.APIKeyRepository
+	userRepo repository.UserRepository
+	logger   *logger.Logger
+}
+
+// NewAPIKeyService 创建 API 密钥服务
+func NewAPIKeyService(keyRepo repository.APIKeyRepository, userRepo repository.UserRepository, logger *logger.Logger) *APIKeyService {
+	return &APIKeyService{
+		keyRepo:  keyRepo,
+		userRepo: userRepo,
+		logger:   logger,
+	}
+}
+
+// CreateKey 创建密钥，返回的明文密钥只在创建时出现一次
+// grantable 为调用方当前拥有的权限，使用 API 密钥调用时只能授予该密钥自身的权限范围
+func (s *APIKeyService) CreateKey(owner *model.User, grantable []model.Permission, name string, scopes model.Permissions, expiresAt *time.Time) (*model.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", fmt.Errorf("%w: name is required", ErrInvalidAPIKey)
+	}
+	if len(scopes) == 0 {
+		return nil, "", fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKey)
+	}
+
+	allowed := make(map[model.Permission]bool, len(grantable))
+	for _, permission := range grantable {
+		allowed[permission] = true
+	}
+
+	seen := make(map[model.Permission]bool)
+	normalized := make(model.Permissions, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = model.Permission(strings.ToLower(strings.TrimSpace(string(scope))))
+		if !scope.Valid() {
+			return nil, "", fmt.Errorf("%w: unknown scope %s", ErrInvalidAPIKey, scope)
+		}
+		if !allowed[scope] {
+			return nil, "", fmt.Errorf("%w: caller cannot grant scope %s", ErrInvalidAPIKey, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", fmt.Errorf("%w: expires_at must be in the future", ErrInvalidAPIKey)
+	}
+
+	secret, err := crypto.GenerateToken(tokenBytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	plaintext := model.APIKeyPrefix + secret
+
+	key := &model.APIKey{
+		Name:      name,
+		Prefix:    plaintext[:apiKeyDisplayLength],
+		KeyHash:   crypto.HashToken(plaintext),
+		Scopes:    normalized,
+		UserID:    owner.ID,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.keyRepo.Create(key); err != nil {
+		return nil, "", err
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"key_id":  key.ID,
+		"name":    key.Name,
+		"user_id": owner.ID,
+	}).Info("API key created")
+	return key, plaintext, nil
+}
+
+// GetKeys 获取密钥列表
+func (s *APIKeyService) GetKeys(page, pageSize int) ([]model.APIKey, int64, error) {
+	offset := (page - 1) * pageSize
+	return s.keyRepo.GetAll(offset, pageSize)
+}
+
+// GetKeyByID 根据ID获取密钥
+func (s *APIKeyService) GetKeyByID(id uint) (*model.APIKey, error) {
+	return s.keyRepo.GetByID(id)
+}
+
+// RevokeKey 吊销密钥
+func (s *APIKeyService) RevokeKey(id uint) error {
+	if err := s.keyRepo.Revoke(id); err != nil {
+		return err
+	}
+	s.logger.WithField("key_id", id).Info("API key revoked")
+	return nil
+}
+
+// AuthenticateAPIKey 校验 API 密钥，返回密钥的创建人和密钥，并记录最近使用时间
+func (s *APIKeyService) AuthenticateAPIKey(plaintext string) (*model.User, *model.APIKey, error) {
+	key, err := s.keyRepo.GetByHash(crypto.HashToken(plaintext))
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(key.UserID)
+	if err != nil || !user.IsActive {
+		return nil, nil, ErrInvalidToken
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyLastUsedInterval {
+		if err := s.keyRepo.UpdateLastUsed(key.ID, now); err != nil {
+			s.logger.WithField("error", err).Warn("Failed to update api key last used time")
+		}
+		key.LastUsedAt = &now
+	}
+
+	return user, key, nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"weex-watchdog/internal/model"
+	"weex-watchdog/internal/repository"
+	"weex-watchdog/pkg/logger"
+)
+
+// auditIgnoredFields 不记录差异的字段
+var auditIgnoredFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+}
+
+// AuditActor 操作人
+type AuditActor struct {
+	UserID   *uint
+	Username string
+	APIKeyID *uint
+	ClientIP string
+}
+
+// AuditService 审计日志服务
+type AuditService struct {
+	auditRepo repository.AuditLogRepository
+	logger    *logger.Logger
+}
+
+// NewAuditService 创建审计日志服务
+func NewAuditService(auditRepo repository.AuditLogRepository, logger *logger.Logger) *AuditService {
+	return &AuditService{
+		auditRepo: auditRepo,
+		logger:    logger,
+	}
+}
+
+// Record 记录一次操作，before/after 为操作前后的对象（新建时 before 为 nil，删除时 after 为 nil），
+// 只保存按 JSON 字段比较有差异的部分。写入失败只记录日志，不影响操作本身
+func (s *AuditService) Record(actor AuditActor, action, targetType, targetID string, before, after interface{}) {
+	changes, err := diffAudit(before, after)
+	if err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"action": action,
+			"error":  err,
+		}).Warn("Failed to compute audit changes")
+	}
+
+	log := &model.AuditLog{
+		ActorID:    actor.UserID,
+		ActorName:  truncateString(actor.Username, 50),
+		APIKeyID:   actor.APIKeyID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Changes:    changes,
+		ClientIP:   actor.ClientIP,
+	}
+	if err := s.auditRepo.Create(log); err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"action":    action,
+			"actor":     actor.Username,
+			"target_id": targetID,
+			"error":     err,
+		}).Error("Failed to write audit log")
+	}
+}
+
+// GetLogs 获取审计日志
+func (s *AuditService) GetLogs(filters map[string]interface{}, page, pageSize int) ([]model.AuditLog, int64, error) {
+	offset := (page - 1) * pageSize
+	return s.auditRepo.GetLogs(filters, offset, pageSize)
+}
+
+// diffAudit 按 JSON 字段比较操作前后的对象，两者都为 nil 时返回 nil
+func diffAudit(before, after interface{}) (model.AuditChanges, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+	if beforeFields == nil && afterFields == nil {
+		return nil, nil
+	}
+
+	changes := make(model.AuditChanges)
+	for key, value := range beforeFields {
+		if auditIgnoredFields[key] {
+			continue
+		}
+		if newValue, ok := afterFields[key]; !ok || !reflect.DeepEqual(value, newValue) {
+			changes[key] = model.AuditChange{Before: value, After: afterFields[key]}
+		}
+	}
+	for key, value := range afterFields {
+		if auditIgnoredFields[key] {
+			continue
+		}
+		if _, ok := beforeFields[key]; !ok {
+			changes[key] = model.AuditChange{Before: nil, After: value}
+		}
+	}
+	return changes, nil
+}
+
+// auditFields 将对象转换为 JSON 字段，json:"-" 的字段（如密码哈希）不会出现
+func auditFields(value interface{}) (map[string]interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
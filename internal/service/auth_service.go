@@ -0,0 +1,393 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"weex-watchdog/internal/config"
+	"weex-watchdog/internal/model"
+	"weex-watchdog/internal/repository"
+	"weex-watchdog/pkg/crypto"
+	"weex-watchdog/pkg/logger"
+)
+
+// 认证相关错误
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrInvalidUser        = errors.New("invalid user")
+)
+
+// minPasswordLength 密码最短长度
+const minPasswordLength = 8
+
+// tokenBytes 令牌随机数长度
+const tokenBytes = 32
+
+// dummyPasswordHash 用户不存在时也执行一次 bcrypt 比较，避免通过响应时间判断用户名是否存在
+var dummyPasswordHash, _ = crypto.HashPassword("weex-watchdog-dummy-password")
+
+// TokenPair 登录或刷新后返回的令牌
+type TokenPair struct {
+	AccessToken      string    `json:"token"`
+	RefreshToken     string    `json:"refresh_token"`
+	AccessExpiresAt  time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// AuthService 用户认证服务
+// 访问令牌和刷新令牌都是随机字符串，数据库只保存哈希；刷新时旧会话失效并签发新的令牌
+type AuthService struct {
+	userRepo    repository.UserRepository
+	sessionRepo repository.SessionRepository
+	throttle    *LoginThrottle
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+	logger      *logger.Logger
+}
+
+// NewAuthService 创建用户认证服务
+func NewAuthService(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, throttleRepo repository.LoginThrottleRepository, authConfig config.AuthConfig, logger *logger.Logger) *AuthService {
+	accessTTL := authConfig.AccessTokenTTL
+	if accessTTL <= 0 {
+		accessTTL = 2 * time.Hour
+	}
+	refreshTTL := authConfig.RefreshTokenTTL
+	if refreshTTL < accessTTL {
+		refreshTTL = 7 * 24 * time.Hour
+	}
+
+	return &AuthService{
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		throttle:    NewLoginThrottle(throttleRepo, authConfig.Lockout, logger),
+		accessTTL:   accessTTL,
+		refreshTTL:  refreshTTL,
+		logger:      logger,
+	}
+}
+
+// EnsureBootstrapAdmin users 表为空时使用配置中的账号创建初始管理员；
+// 已有用户但没有管理员时（如从无角色的版本升级），将配置中的用户提升为管理员
+func (s *AuthService) EnsureBootstrapAdmin(username, password string) error {
+	count, err := s.userRepo.Count()
+	if err != nil {
+		return fmt.Errorf("failed to count users: %w", err)
+	}
+	if count > 0 {
+		return s.ensureAdminExists(username)
+	}
+	if username == "" || password == "" {
+		return errors.New("no user exists and auth.username/auth.password are not configured")
+	}
+
+	user := &model.User{Username: username, Role: model.RoleAdmin, IsActive: true}
+	if err := s.setPassword(user, password); err != nil {
+		return err
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return fmt.Errorf("failed to create bootstrap admin: %w", err)
+	}
+
+	s.logger.WithField("username", username).Info("Bootstrap admin created")
+	return nil
+}
+
+// ensureAdminExists 没有管理员时将配置中的用户提升为管理员
+func (s *AuthService) ensureAdminExists(username string) error {
+	admins, err := s.userRepo.CountByRole(model.RoleAdmin)
+	if err != nil {
+		return fmt.Errorf("failed to count admins: %w", err)
+	}
+	if admins > 0 || username == "" {
+		return nil
+	}
+
+	user, err := s.userRepo.GetByUsername(username)
+	if err != nil {
+		s.logger.WithField("username", username).Warn("No admin user exists and the bootstrap user was not found")
+		return nil
+	}
+	user.Role = model.RoleAdmin
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("failed to promote bootstrap admin: %w", err)
+	}
+
+	s.logger.WithField("username", username).Info("Bootstrap user promoted to admin")
+	return nil
+}
+
+// Login 校验用户名密码并创建会话
+// 校验密码前先预占一次尝试，用户名或IP处于锁定中时直接返回 *LoginLockedError，不校验密码
+func (s *AuthService) Login(username, password, clientIP, userAgent string) (*TokenPair, *model.User, error) {
+	if err := s.throttle.Reserve(clientIP, username); err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"username":  username,
+			"client_ip": clientIP,
+		}).Warn("Login rejected, too many failed attempts")
+		return nil, nil, err
+	}
+
+	user, err := s.userRepo.GetByUsername(username)
+	if err != nil {
+		crypto.CheckPassword(dummyPasswordHash, password)
+		s.loginFailed(clientIP, username, "unknown user")
+		return nil, nil, ErrInvalidCredentials
+	}
+	if !crypto.CheckPassword(user.PasswordHash, password) {
+		s.loginFailed(clientIP, username, "wrong password")
+		return nil, nil, ErrInvalidCredentials
+	}
+	if !user.IsActive {
+		s.loginFailed(clientIP, username, "user disabled")
+		return nil, nil, ErrInvalidCredentials
+	}
+	s.throttle.RecordSuccess(clientIP, username)
+
+	tokens, err := s.createSession(user.ID, clientIP, userAgent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if err := s.userRepo.UpdateLastLogin(user.ID, now); err != nil {
+		s.logger.WithField("error", err).Warn("Failed to update last login time")
+	}
+	user.LastLoginAt = &now
+
+	return tokens, user, nil
+}
+
+// loginFailed 记录登录失败
+func (s *AuthService) loginFailed(clientIP, username, reason string) {
+	s.logger.WithFields(map[string]interface{}{
+		"username":  username,
+		"client_ip": clientIP,
+		"reason":    reason,
+	}).Warn("Login failed")
+	s.throttle.RecordFailure(clientIP, username)
+}
+
+// Authenticate 校验访问令牌，返回令牌所属的用户和会话
+func (s *AuthService) Authenticate(accessToken string) (*model.User, *model.UserSession, error) {
+	session, err := s.sessionRepo.GetByAccessTokenHash(crypto.HashToken(accessToken))
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	if session.RevokedAt != nil || time.Now().After(session.AccessExpiresAt) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(session.UserID)
+	if err != nil || !user.IsActive {
+		return nil, nil, ErrInvalidToken
+	}
+
+	return user, session, nil
+}
+
+// Refresh 使用刷新令牌签发新的令牌，旧会话随即失效，同一个刷新令牌只能使用一次
+func (s *AuthService) Refresh(refreshToken, clientIP, userAgent string) (*TokenPair, error) {
+	session, err := s.sessionRepo.GetByRefreshTokenHash(crypto.HashToken(refreshToken))
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if session.RevokedAt != nil || time.Now().After(session.RefreshExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(session.UserID)
+	if err != nil || !user.IsActive {
+		return nil, ErrInvalidToken
+	}
+
+	revoked, err := s.sessionRepo.Revoke(session.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if !revoked {
+		// 并发刷新时只有一个请求成功
+		return nil, ErrInvalidToken
+	}
+
+	return s.createSession(user.ID, clientIP, userAgent)
+}
+
+// Logout 使会话失效
+func (s *AuthService) Logout(sessionID uint) error {
+	_, err := s.sessionRepo.Revoke(sessionID)
+	return err
+}
+
+// StartSessionCleanup 每小时清理刷新令牌已过期的会话和过期的登录失败计数，ctx 取消后退出
+func (s *AuthService) StartSessionCleanup(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.sessionRepo.DeleteExpired(time.Now())
+			if err != nil {
+				s.logger.WithField("error", err).Error("Failed to delete expired sessions")
+			} else if deleted > 0 {
+				s.logger.WithField("deleted", deleted).Debug("Expired sessions deleted")
+			}
+			if _, err := s.throttle.Cleanup(); err != nil {
+				s.logger.WithField("error", err).Error("Failed to delete stale login throttles")
+			}
+		}
+	}
+}
+
+// GetUsers 获取用户列表
+func (s *AuthService) GetUsers(page, pageSize int) ([]model.User, int64, error) {
+	offset := (page - 1) * pageSize
+	return s.userRepo.GetAll(offset, pageSize)
+}
+
+// GetUserByID 根据ID获取用户
+func (s *AuthService) GetUserByID(id uint) (*model.User, error) {
+	return s.userRepo.GetByID(id)
+}
+
+// CreateUser 创建用户
+func (s *AuthService) CreateUser(username, password string, role model.Role, isActive bool) (*model.User, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return nil, fmt.Errorf("%w: username is required", ErrInvalidUser)
+	}
+	if !role.Valid() {
+		return nil, fmt.Errorf("%w: role must be admin, operator or viewer", ErrInvalidUser)
+	}
+	if existing, err := s.userRepo.GetByUsername(username); err == nil && existing != nil {
+		return nil, fmt.Errorf("%w: user %s already exists", ErrInvalidUser, username)
+	}
+
+	user := &model.User{Username: username, Role: role, IsActive: isActive}
+	if err := s.setPassword(user, password); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateUser 修改用户密码、角色或启用状态，重置密码或停用后该用户的所有会话失效
+func (s *AuthService) UpdateUser(user *model.User, password *string, role *model.Role, isActive *bool) error {
+	if password != nil {
+		if err := s.setPassword(user, *password); err != nil {
+			return err
+		}
+	}
+	if role != nil {
+		if !role.Valid() {
+			return fmt.Errorf("%w: role must be admin, operator or viewer", ErrInvalidUser)
+		}
+		user.Role = *role
+	}
+	if isActive != nil {
+		user.IsActive = *isActive
+	}
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+	if password != nil || !user.IsActive {
+		return s.sessionRepo.RevokeByUser(user.ID)
+	}
+	return nil
+}
+
+// DeleteUser 删除用户及其会话
+func (s *AuthService) DeleteUser(id uint) error {
+	if err := s.sessionRepo.RevokeByUser(id); err != nil {
+		return err
+	}
+	return s.userRepo.Delete(id)
+}
+
+// ChangePassword 用户修改自己的密码，需要校验原密码，修改后包括当前会话在内的所有会话失效，需要重新登录
+func (s *AuthService) ChangePassword(user *model.User, oldPassword, newPassword string) error {
+	if !crypto.CheckPassword(user.PasswordHash, oldPassword) {
+		return ErrInvalidCredentials
+	}
+	if err := s.setPassword(user, newPassword); err != nil {
+		return err
+	}
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+	if err := s.sessionRepo.RevokeByUser(user.ID); err != nil {
+		return err
+	}
+	s.logger.WithField("user_id", user.ID).Info("User password changed, sessions revoked")
+	return nil
+}
+
+// setPassword 校验密码强度并写入哈希
+func (s *AuthService) setPassword(user *model.User, password string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("%w: password must be at least %d characters", ErrInvalidUser, minPasswordLength)
+	}
+	hash, err := crypto.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	user.PasswordHash = hash
+	return nil
+}
+
+// createSession 创建会话并返回明文令牌
+func (s *AuthService) createSession(userID uint, clientIP, userAgent string) (*TokenPair, error) {
+	accessToken, err := crypto.GenerateToken(tokenBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	refreshToken, err := crypto.GenerateToken(tokenBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	now := time.Now()
+	session := &model.UserSession{
+		UserID:           userID,
+		AccessTokenHash:  crypto.HashToken(accessToken),
+		RefreshTokenHash: crypto.HashToken(refreshToken),
+		AccessExpiresAt:  now.Add(s.accessTTL),
+		RefreshExpiresAt: now.Add(s.refreshTTL),
+		ClientIP:         clientIP,
+		UserAgent:        truncateString(userAgent, 255),
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		AccessExpiresAt:  session.AccessExpiresAt,
+		RefreshExpiresAt: session.RefreshExpiresAt,
+	}, nil
+}
+
+// truncateString 按字节截断字符串，不截断多字节字符
+func truncateString(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	for limit > 0 && !isRuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit]
+}
+
+// isRuneStart 字节是否为 UTF-8 字符的起始字节
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
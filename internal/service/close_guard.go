@@ -0,0 +1,105 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"weex-watchdog/internal/model"
+)
+
+// closeGuard 平仓确认，防止网关短暂返回空列表时误判平仓
+// 订单需连续多次拉取缺失且缺失时长超过宽限期，才会被判定为平仓
+type closeGuard struct {
+	mu                     sync.Mutex
+	confirmPolls           int           // 连续缺失多少次后确认平仓
+	gracePeriod            time.Duration // 首次缺失后至少等待的时长
+	suspiciousMinOrders    int           // 持仓数不少于该值时全部消失视为可疑
+	suspiciousConfirmPolls int           // 可疑情况下确认平仓需要的连续缺失次数
+	missing                map[string]*missingOrder
+	suspiciousPolls        int64
+}
+
+// missingOrder 缺失订单的确认状态
+type missingOrder struct {
+	traderUserID string
+	count        int
+	since        time.Time
+}
+
+// newCloseGuard 创建平仓确认器
+func newCloseGuard(confirmPolls int, gracePeriod time.Duration, suspiciousMinOrders, suspiciousConfirmPolls int) *closeGuard {
+	if confirmPolls <= 0 {
+		confirmPolls = 1
+	}
+	if suspiciousConfirmPolls < confirmPolls {
+		suspiciousConfirmPolls = confirmPolls
+	}
+	return &closeGuard{
+		confirmPolls:           confirmPolls,
+		gracePeriod:            gracePeriod,
+		suspiciousMinOrders:    suspiciousMinOrders,
+		suspiciousConfirmPolls: suspiciousConfirmPolls,
+		missing:                make(map[string]*missingOrder),
+	}
+}
+
+// observe 记录一次拉取结果，返回确认已平仓的订单
+// suspicious 表示本次拉取所有持仓同时消失，确认平仓需要更多次数
+func (g *closeGuard) observe(traderUserID string, activeOrders []model.OrderHistory, currentOrderIDs map[string]bool, now time.Time) (confirmed []model.OrderHistory, pending int, suspicious bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	suspicious = g.suspiciousMinOrders > 0 &&
+		len(currentOrderIDs) == 0 &&
+		len(activeOrders) >= g.suspiciousMinOrders
+	requiredPolls := g.confirmPolls
+	if suspicious {
+		requiredPolls = g.suspiciousConfirmPolls
+		g.suspiciousPolls++
+	}
+
+	for _, order := range activeOrders {
+		key := traderUserID + ":" + order.OrderID
+
+		// 订单仍在持仓中，清除缺失状态
+		if currentOrderIDs[order.OrderID] {
+			delete(g.missing, key)
+			continue
+		}
+
+		state, exists := g.missing[key]
+		if !exists {
+			state = &missingOrder{traderUserID: traderUserID, since: now}
+			g.missing[key] = state
+		}
+		state.count++
+
+		if state.count >= requiredPolls && now.Sub(state.since) >= g.gracePeriod {
+			confirmed = append(confirmed, order)
+			delete(g.missing, key)
+		} else {
+			pending++
+		}
+	}
+
+	return confirmed, pending, suspicious
+}
+
+// retain 只保留仍在监控的交易员的缺失状态，停用或删除的交易员不会再被拉取，其状态需要清除
+func (g *closeGuard) retain(activeTraders map[string]bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for key, state := range g.missing {
+		if !activeTraders[state.traderUserID] {
+			delete(g.missing, key)
+		}
+	}
+}
+
+// stats 返回待确认的缺失订单数和可疑拉取次数
+func (g *closeGuard) stats() (pending int, suspiciousPolls int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.missing), g.suspiciousPolls
+}
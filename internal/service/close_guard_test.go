@@ -0,0 +1,180 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"weex-watchdog/internal/model"
+)
+
+// guardPoll 一次拉取：offset 为距第一次拉取的时长，present 为本次仍在持仓中的订单
+type guardPoll struct {
+	offset  time.Duration
+	present []string
+}
+
+func TestCloseGuardObserve(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	active := func(ids ...string) []model.OrderHistory {
+		orders := make([]model.OrderHistory, 0, len(ids))
+		for _, id := range ids {
+			orders = append(orders, model.OrderHistory{TraderUserID: "1", OrderID: id})
+		}
+		return orders
+	}
+
+	tests := []struct {
+		name                   string
+		confirmPolls           int
+		gracePeriod            time.Duration
+		suspiciousMinOrders    int
+		suspiciousConfirmPolls int
+		active                 []string
+		polls                  []guardPoll
+		// 每次拉取确认平仓的订单数
+		wantConfirmed []int
+		wantPending   int // 最后一次拉取后仍待确认的订单数
+		wantSuspicion bool
+	}{
+		{
+			name:          "confirmed after confirm polls",
+			confirmPolls:  2,
+			active:        []string{"a", "b"},
+			polls:         []guardPoll{{0, []string{"b"}}, {time.Minute, []string{"b"}}},
+			wantConfirmed: []int{0, 1},
+			wantPending:   0,
+		},
+		{
+			name:          "reappears after N-1 misses",
+			confirmPolls:  3,
+			active:        []string{"a"},
+			polls:         []guardPoll{{0, nil}, {time.Minute, nil}, {2 * time.Minute, []string{"a"}}, {3 * time.Minute, nil}},
+			wantConfirmed: []int{0, 0, 0, 0},
+			// 重新出现后计数清零，第四次只算第一次缺失
+			wantPending: 1,
+		},
+		{
+			name:          "grace period not elapsed",
+			confirmPolls:  2,
+			gracePeriod:   time.Hour,
+			active:        []string{"a"},
+			polls:         []guardPoll{{0, nil}, {time.Minute, nil}, {30 * time.Minute, nil}},
+			wantConfirmed: []int{0, 0, 0},
+			wantPending:   1,
+		},
+		{
+			name:          "grace period elapsed",
+			confirmPolls:  2,
+			gracePeriod:   time.Hour,
+			active:        []string{"a"},
+			polls:         []guardPoll{{0, nil}, {time.Minute, nil}, {time.Hour, nil}},
+			wantConfirmed: []int{0, 0, 1},
+			wantPending:   0,
+		},
+		{
+			name:                   "empty snapshot needs suspicious confirm polls",
+			confirmPolls:           2,
+			suspiciousMinOrders:    3,
+			suspiciousConfirmPolls: 4,
+			active:                 []string{"a", "b", "c"},
+			polls:                  []guardPoll{{0, nil}, {time.Minute, nil}, {2 * time.Minute, nil}, {3 * time.Minute, nil}},
+			wantConfirmed:          []int{0, 0, 0, 3},
+			wantPending:            0,
+			wantSuspicion:          true,
+		},
+		{
+			name:                   "empty snapshot below suspicious threshold",
+			confirmPolls:           2,
+			suspiciousMinOrders:    3,
+			suspiciousConfirmPolls: 4,
+			active:                 []string{"a", "b"},
+			polls:                  []guardPoll{{0, nil}, {time.Minute, nil}},
+			wantConfirmed:          []int{0, 2},
+			wantPending:            0,
+		},
+		{
+			name:                   "partial snapshot is not suspicious",
+			confirmPolls:           2,
+			suspiciousMinOrders:    3,
+			suspiciousConfirmPolls: 4,
+			active:                 []string{"a", "b", "c"},
+			polls:                  []guardPoll{{0, []string{"c"}}, {time.Minute, []string{"c"}}},
+			wantConfirmed:          []int{0, 2},
+			wantPending:            0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := newCloseGuard(tt.confirmPolls, tt.gracePeriod, tt.suspiciousMinOrders, tt.suspiciousConfirmPolls)
+			confirmedIDs := make(map[string]bool)
+			var suspicious bool
+
+			for i, poll := range tt.polls {
+				// 已确认平仓的订单不再是持仓中的订单
+				var remaining []string
+				for _, id := range tt.active {
+					if !confirmedIDs[id] {
+						remaining = append(remaining, id)
+					}
+				}
+				current := make(map[string]bool, len(poll.present))
+				for _, id := range poll.present {
+					current[id] = true
+				}
+
+				confirmed, _, polledSuspicious := guard.observe("1", active(remaining...), current, base.Add(poll.offset))
+				if len(confirmed) != tt.wantConfirmed[i] {
+					t.Fatalf("poll %d: confirmed %d orders, want %d", i, len(confirmed), tt.wantConfirmed[i])
+				}
+				for _, order := range confirmed {
+					confirmedIDs[order.OrderID] = true
+				}
+				suspicious = suspicious || polledSuspicious
+			}
+
+			pending, suspiciousPolls := guard.stats()
+			if pending != tt.wantPending {
+				t.Errorf("pending = %d, want %d", pending, tt.wantPending)
+			}
+			if suspicious != tt.wantSuspicion || (suspiciousPolls > 0) != tt.wantSuspicion {
+				t.Errorf("suspicious = %v (%d polls), want %v", suspicious, suspiciousPolls, tt.wantSuspicion)
+			}
+		})
+	}
+}
+
+func TestCloseGuardRetain(t *testing.T) {
+	guard := newCloseGuard(3, 0, 0, 0)
+	now := time.Now()
+
+	for _, traderID := range []string{"1", "2", "3"} {
+		orders := []model.OrderHistory{{TraderUserID: traderID, OrderID: "a"}, {TraderUserID: traderID, OrderID: "b"}}
+		guard.observe(traderID, orders, map[string]bool{}, now)
+	}
+	if pending, _ := guard.stats(); pending != 6 {
+		t.Fatalf("pending = %d, want 6", pending)
+	}
+
+	// 交易员 2 已停用，交易员 3 已删除
+	guard.retain(map[string]bool{"1": true})
+	if pending, _ := guard.stats(); pending != 2 {
+		t.Fatalf("pending after retain = %d, want 2", pending)
+	}
+
+	// 仍在监控的交易员保留缺失计数：再缺失两次即确认
+	orders := []model.OrderHistory{{TraderUserID: "1", OrderID: "a"}, {TraderUserID: "1", OrderID: "b"}}
+	guard.observe("1", orders, map[string]bool{}, now)
+	confirmed, _, _ := guard.observe("1", orders, map[string]bool{}, now)
+	if len(confirmed) != 2 {
+		t.Fatalf("confirmed = %d, want 2", len(confirmed))
+	}
+
+	// 重新启用的交易员从头计数
+	orders = []model.OrderHistory{{TraderUserID: "2", OrderID: "a"}}
+	guard.observe("2", orders, map[string]bool{}, now)
+	confirmed, pendingNow, _ := guard.observe("2", orders, map[string]bool{}, now)
+	if len(confirmed) != 0 || pendingNow != 1 {
+		t.Fatalf("re-enabled trader: confirmed = %d, pending = %d, want 0 and 1", len(confirmed), pendingNow)
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"weex-watchdog/internal/repository"
+	"weex-watchdog/pkg/logger"
+	"weex-watchdog/pkg/notification"
+)
+
+// EmailDigestService 定期汇总开平仓记录并发送邮件
+type EmailDigestService struct {
+	orderRepo repository.OrderRepository
+	client    *notification.EmailNotificationClient
+	logger    *logger.Logger
+}
+
+// NewEmailDigestService 创建汇总邮件服务
+func NewEmailDigestService(orderRepo repository.OrderRepository, client *notification.EmailNotificationClient, logger *logger.Logger) *EmailDigestService {
+	return &EmailDigestService{
+		orderRepo: orderRepo,
+		client:    client,
+		logger:    logger,
+	}
+}
+
+// Start 在每个汇总周期结束时（整点或零点）发送上一周期的汇总，ctx 取消后退出
+func (s *EmailDigestService) Start(ctx context.Context) {
+	interval := s.client.DigestInterval()
+	s.logger.WithField("interval", interval.String()).Info("Email digest started")
+
+	for {
+		next := nextDigestBoundary(time.Now(), interval)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.logger.Info("Email digest stopped")
+			return
+		case <-timer.C:
+		}
+
+		s.sendDigest(next.Add(-interval), next)
+	}
+}
+
+// sendDigest 发送时间段内的汇总
+func (s *EmailDigestService) sendDigest(from, to time.Time) {
+	orders, err := s.orderRepo.GetActivityBetween(from, to)
+	if err != nil {
+		s.logger.WithField("error", err).Error("Failed to load orders for email digest")
+		return
+	}
+	if len(orders) == 0 {
+		s.logger.Debug("No order activity, email digest skipped")
+		return
+	}
+
+	if err := s.client.SendDigest(orders, from, to); err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"from":  from,
+			"to":    to,
+			"error": err,
+		}).Error("Failed to send email digest")
+		return
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"from":   from,
+		"to":     to,
+		"orders": len(orders),
+	}).Info("Email digest sent")
+}
+
+// nextDigestBoundary 下一个汇总时间点：按小时汇总为下一个整点，按天汇总为下一个本地零点
+func nextDigestBoundary(now time.Time, interval time.Duration) time.Time {
+	if interval < 24*time.Hour {
+		return now.Truncate(time.Hour).Add(time.Hour)
+	}
+	year, month, day := now.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+}
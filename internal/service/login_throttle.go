@@ -0,0 +1,238 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"weex-watchdog/internal/config"
+	"weex-watchdog/internal/model"
+	"weex-watchdog/internal/repository"
+	"weex-watchdog/pkg/logger"
+)
+
+// ErrLoginLocked 登录失败次数过多，暂时锁定
+var ErrLoginLocked = errors.New("too many failed login attempts")
+
+// LoginLockedError 登录被锁定，RetryAfter 为剩余锁定时长
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+// Error 实现 error 接口
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("%s, try again in %s", ErrLoginLocked, e.RetryAfter.Round(time.Second))
+}
+
+// Unwrap 使 errors.Is(err, ErrLoginLocked) 成立
+func (e *LoginLockedError) Unwrap() error {
+	return ErrLoginLocked
+}
+
+// LoginThrottle 按用户名和IP限制登录失败次数，计数保存在数据库中，重启后仍然有效
+type LoginThrottle struct {
+	repo   repository.LoginThrottleRepository
+	config config.LockoutConfig
+	mu     sync.Mutex
+	logger *logger.Logger
+}
+
+// NewLoginThrottle 创建登录失败限制
+func NewLoginThrottle(repo repository.LoginThrottleRepository, lockoutConfig config.LockoutConfig, logger *logger.Logger) *LoginThrottle {
+	if lockoutConfig.MaxAttempts <= 0 {
+		lockoutConfig.MaxAttempts = 5
+	}
+	if lockoutConfig.IPMaxAttempts <= 0 {
+		lockoutConfig.IPMaxAttempts = 20
+	}
+	if lockoutConfig.Window <= 0 {
+		lockoutConfig.Window = 15 * time.Minute
+	}
+	if lockoutConfig.BaseDuration <= 0 {
+		lockoutConfig.BaseDuration = time.Minute
+	}
+	if lockoutConfig.MaxDuration < lockoutConfig.BaseDuration {
+		lockoutConfig.MaxDuration = 24 * time.Hour
+	}
+
+	return &LoginThrottle{
+		repo:   repo,
+		config: lockoutConfig,
+		logger: logger,
+	}
+}
+
+// Reserve 校验密码前预占一次尝试，处于锁定中或尝试次数已达上限时返回 *LoginLockedError
+// 预占在同一把锁内完成读取、判断和计数，并发的登录请求不会同时通过检查；
+// 失败时调用 RecordFailure 决定是否锁定，成功时调用 RecordSuccess 释放
+func (t *LoginThrottle) Reserve(clientIP, username string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	throttles, err := t.load(clientIP, username)
+	if err != nil {
+		// 计数不可用时不阻止登录，密码校验仍然有效
+		t.logger.WithField("error", err).Error("Failed to load login throttles")
+		return nil
+	}
+
+	now := time.Now()
+	var retryAfter time.Duration
+	for key, throttle := range throttles {
+		t.expire(throttle, now)
+		remaining := time.Duration(0)
+		switch {
+		case throttle.LockedUntil != nil && throttle.LockedUntil.After(now):
+			remaining = throttle.LockedUntil.Sub(now)
+		case throttle.Failures >= t.limit(key):
+			// 上限内的尝试仍在进行中，按即将触发的锁定时长拒绝
+			remaining = t.lockoutDuration(throttle.Lockouts + 1)
+		}
+		if remaining > retryAfter {
+			retryAfter = remaining
+		}
+	}
+	if retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+
+	for key, throttle := range throttles {
+		throttle.Failures++
+		throttle.LastFailureAt = now
+		t.save(key, throttle)
+	}
+	return nil
+}
+
+// RecordFailure 预占的尝试失败，计数达到上限时开始锁定
+func (t *LoginThrottle) RecordFailure(clientIP, username string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	throttles, err := t.load(clientIP, username)
+	if err != nil {
+		t.logger.WithField("error", err).Error("Failed to load login throttles")
+		return
+	}
+
+	now := time.Now()
+	for key, throttle := range throttles {
+		if throttle.Failures < t.limit(key) || (throttle.LockedUntil != nil && throttle.LockedUntil.After(now)) {
+			continue
+		}
+
+		throttle.Lockouts++
+		lockedUntil := now.Add(t.lockoutDuration(throttle.Lockouts))
+		throttle.LockedUntil = &lockedUntil
+		throttle.Failures = 0
+		t.save(key, throttle)
+
+		t.logger.WithFields(map[string]interface{}{
+			"key":          key,
+			"lockouts":     throttle.Lockouts,
+			"locked_until": lockedUntil,
+		}).Warn("Login locked after repeated failures")
+	}
+}
+
+// RecordSuccess 登录成功后清除该用户名的失败计数，并释放IP预占的一次尝试，IP 的失败计数按窗口自然过期
+func (t *LoginThrottle) RecordSuccess(clientIP, username string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.repo.DeleteByKey(userThrottleKey(username)); err != nil {
+		t.logger.WithField("error", err).Warn("Failed to reset login throttle")
+	}
+
+	ipKey := ipThrottleKey(clientIP)
+	throttles, err := t.repo.GetByKeys([]string{ipKey})
+	if err != nil {
+		t.logger.WithField("error", err).Warn("Failed to load login throttle")
+		return
+	}
+	for i := range throttles {
+		if throttles[i].Failures > 0 {
+			throttles[i].Failures--
+			t.save(ipKey, &throttles[i])
+		}
+	}
+}
+
+// Cleanup 删除已过期的计数
+func (t *LoginThrottle) Cleanup() (int64, error) {
+	return t.repo.DeleteStale(time.Now().Add(-t.config.MaxDuration))
+}
+
+// lockoutDuration 第 n 次锁定的时长
+func (t *LoginThrottle) lockoutDuration(lockouts int) time.Duration {
+	duration := t.config.BaseDuration
+	for i := 1; i < lockouts; i++ {
+		duration *= 2
+		if duration >= t.config.MaxDuration {
+			return t.config.MaxDuration
+		}
+	}
+	return duration
+}
+
+// load 读取用户名和IP的计数，不存在时返回新的空计数
+func (t *LoginThrottle) load(clientIP, username string) (map[string]*model.LoginThrottle, error) {
+	userKey, ipKey := userThrottleKey(username), ipThrottleKey(clientIP)
+	throttles, err := t.repo.GetByKeys([]string{userKey, ipKey})
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]*model.LoginThrottle{
+		userKey: {Key: userKey},
+		ipKey:   {Key: ipKey},
+	}
+	for i := range throttles {
+		result[throttles[i].Key] = &throttles[i]
+	}
+	return result, nil
+}
+
+// expire 距上次尝试超过窗口时重新计数，超过最长锁定时长时锁定次数也重新计算
+func (t *LoginThrottle) expire(throttle *model.LoginThrottle, now time.Time) {
+	if throttle.LastFailureAt.IsZero() {
+		return
+	}
+	idle := now.Sub(throttle.LastFailureAt)
+	if idle > t.config.Window {
+		throttle.Failures = 0
+	}
+	if idle > t.config.MaxDuration {
+		throttle.Lockouts = 0
+	}
+}
+
+// limit 计数键对应的失败次数上限
+func (t *LoginThrottle) limit(key string) int {
+	if strings.HasPrefix(key, "ip:") {
+		return t.config.IPMaxAttempts
+	}
+	return t.config.MaxAttempts
+}
+
+// save 保存计数，失败时只记录日志
+func (t *LoginThrottle) save(key string, throttle *model.LoginThrottle) {
+	if err := t.repo.Save(throttle); err != nil {
+		t.logger.WithFields(map[string]interface{}{
+			"key":   key,
+			"error": err,
+		}).Error("Failed to save login throttle")
+	}
+}
+
+// userThrottleKey 用户名的计数键，忽略大小写和首尾空白
+func userThrottleKey(username string) string {
+	return truncateString("user:"+strings.ToLower(strings.TrimSpace(username)), 120)
+}
+
+// ipThrottleKey IP 的计数键
+func ipThrottleKey(clientIP string) string {
+	return truncateString("ip:"+clientIP, 120)
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"weex-watchdog/internal/config"
+	"weex-watchdog/internal/model"
+	"weex-watchdog/pkg/logger"
+)
+
+// fakeLoginThrottleRepository 内存中的登录失败计数仓库
+type fakeLoginThrottleRepository struct {
+	mu        sync.Mutex
+	throttles map[string]model.LoginThrottle
+}
+
+func newFakeLoginThrottleRepository() *fakeLoginThrottleRepository {
+	return &fakeLoginThrottleRepository{throttles: make(map[string]model.LoginThrottle)}
+}
+
+func (r *fakeLoginThrottleRepository) GetByKeys(keys []string) ([]model.LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []model.LoginThrottle
+	for _, key := range keys {
+		if throttle, ok := r.throttles[key]; ok {
+			result = append(result, throttle)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeLoginThrottleRepository) Save(throttle *model.LoginThrottle) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.throttles[throttle.Key] = *throttle
+	return nil
+}
+
+func (r *fakeLoginThrottleRepository) DeleteByKey(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.throttles, key)
+	return nil
+}
+
+func (r *fakeLoginThrottleRepository) DeleteStale(before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	for key, throttle := range r.throttles {
+		if throttle.LastFailureAt.Before(before) && (throttle.LockedUntil == nil || throttle.LockedUntil.Before(before)) {
+			delete(r.throttles, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// get 读取计数，不存在时返回零值
+func (r *fakeLoginThrottleRepository) get(key string) model.LoginThrottle {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.throttles[key]
+}
+
+// shift 把计数的时间往前移动 d，模拟时间流逝
+func (r *fakeLoginThrottleRepository) shift(key string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	throttle := r.throttles[key]
+	throttle.LastFailureAt = throttle.LastFailureAt.Add(-d)
+	if throttle.LockedUntil != nil {
+		lockedUntil := throttle.LockedUntil.Add(-d)
+		throttle.LockedUntil = &lockedUntil
+	}
+	r.throttles[key] = throttle
+}
+
+func newTestLoginThrottle(repo *fakeLoginThrottleRepository) *LoginThrottle {
+	return NewLoginThrottle(repo, config.LockoutConfig{
+		MaxAttempts:   3,
+		IPMaxAttempts: 10,
+		Window:        15 * time.Minute,
+		BaseDuration:  time.Minute,
+		MaxDuration:   10 * time.Minute,
+	}, logger.NewLogger(&logger.Config{Level: "panic"}))
+}
+
+// failLogins 连续 n 次预占并记录失败
+func failLogins(t *testing.T, throttle *LoginThrottle, clientIP, username string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := throttle.Reserve(clientIP, username); err != nil {
+			t.Fatalf("attempt %d: Reserve = %v, want allowed", i+1, err)
+		}
+		throttle.RecordFailure(clientIP, username)
+	}
+}
+
+// assertLocked 校验预占被拒绝，剩余锁定时长在 (want-5s, want] 之间
+func assertLocked(t *testing.T, err error, want time.Duration) {
+	t.Helper()
+	var locked *LoginLockedError
+	if !errors.As(err, &locked) || !errors.Is(err, ErrLoginLocked) {
+		t.Fatalf("Reserve = %v, want *LoginLockedError", err)
+	}
+	if locked.RetryAfter > want || locked.RetryAfter <= want-5*time.Second {
+		t.Fatalf("RetryAfter = %s, want about %s", locked.RetryAfter, want)
+	}
+}
+
+func TestLoginThrottleLocksAfterMaxAttempts(t *testing.T) {
+	repo := newFakeLoginThrottleRepository()
+	throttle := newTestLoginThrottle(repo)
+
+	failLogins(t, throttle, "10.0.0.1", "Alice", 3)
+
+	// 用户名忽略大小写，换IP也无法继续尝试
+	assertLocked(t, throttle.Reserve("10.0.0.2", " alice "), time.Minute)
+	if got := repo.get("user:alice"); got.Lockouts != 1 || got.Failures != 0 || got.LockedUntil == nil {
+		t.Fatalf("user throttle = %+v, want one lockout", got)
+	}
+}
+
+func TestLoginThrottleReservedAttemptsBlockFurtherAttempts(t *testing.T) {
+	repo := newFakeLoginThrottleRepository()
+	throttle := newTestLoginThrottle(repo)
+
+	// 三次尝试仍在校验密码，尚未记录结果
+	for i := 0; i < 3; i++ {
+		if err := throttle.Reserve("10.0.0.1", "alice"); err != nil {
+			t.Fatalf("attempt %d: Reserve = %v, want allowed", i+1, err)
+		}
+	}
+	assertLocked(t, throttle.Reserve("10.0.0.1", "alice"), time.Minute)
+
+	// 进行中的尝试全部失败后进入锁定
+	for i := 0; i < 3; i++ {
+		throttle.RecordFailure("10.0.0.1", "alice")
+	}
+	if got := repo.get("user:alice"); got.Lockouts != 1 {
+		t.Fatalf("lockouts = %d, want 1", got.Lockouts)
+	}
+	assertLocked(t, throttle.Reserve("10.0.0.1", "alice"), time.Minute)
+}
+
+func TestLoginThrottleLockoutDuration(t *testing.T) {
+	throttle := newTestLoginThrottle(newFakeLoginThrottleRepository())
+
+	tests := []struct {
+		lockouts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{4, 8 * time.Minute},
+		{5, 10 * time.Minute},
+		{40, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := throttle.lockoutDuration(tt.lockouts); got != tt.want {
+			t.Errorf("lockoutDuration(%d) = %s, want %s", tt.lockouts, got, tt.want)
+		}
+	}
+}
+
+func TestLoginThrottleLockoutEscalates(t *testing.T) {
+	repo := newFakeLoginThrottleRepository()
+	throttle := newTestLoginThrottle(repo)
+
+	for i, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute} {
+		// 每轮换一个IP，只验证用户名的锁定
+		clientIP := "10.0.0." + strconv.Itoa(i+1)
+		failLogins(t, throttle, clientIP, "alice", 3)
+		if got := repo.get("user:alice"); got.Lockouts != i+1 {
+			t.Fatalf("lockouts = %d, want %d", got.Lockouts, i+1)
+		}
+		assertLocked(t, throttle.Reserve(clientIP, "alice"), want)
+
+		// 等待锁定结束，仍在最长锁定时长内，锁定次数保留
+		repo.shift("user:alice", want)
+	}
+}
+
+func TestLoginThrottleWindowExpiry(t *testing.T) {
+	repo := newFakeLoginThrottleRepository()
+	throttle := newTestLoginThrottle(repo)
+
+	failLogins(t, throttle, "10.0.0.1", "alice", 2)
+
+	// 超过窗口后重新计数，又可以尝试三次
+	repo.shift("user:alice", 16*time.Minute)
+	failLogins(t, throttle, "10.0.0.1", "alice", 3)
+	assertLocked(t, throttle.Reserve("10.0.0.1", "alice"), time.Minute)
+
+	// 超过最长锁定时长后锁定次数也重新计算
+	repo.shift("user:alice", 11*time.Minute)
+	failLogins(t, throttle, "10.0.0.1", "alice", 3)
+	if got := repo.get("user:alice"); got.Lockouts != 1 {
+		t.Fatalf("lockouts = %d, want 1 after idle period", got.Lockouts)
+	}
+}
+
+func TestLoginThrottleRecordSuccess(t *testing.T) {
+	repo := newFakeLoginThrottleRepository()
+	throttle := newTestLoginThrottle(repo)
+
+	failLogins(t, throttle, "10.0.0.1", "alice", 2)
+	failLogins(t, throttle, "10.0.0.1", "bob", 2)
+	if err := throttle.Reserve("10.0.0.1", "bob"); err != nil {
+		t.Fatalf("Reserve = %v, want allowed", err)
+	}
+	throttle.RecordSuccess("10.0.0.1", "bob")
+
+	if repo.get("user:bob").Key != "" {
+		t.Errorf("user:bob throttle = %+v, want deleted", repo.get("user:bob"))
+	}
+	if got := repo.get("user:alice"); got.Failures != 2 {
+		t.Errorf("user:alice failures = %d, want 2", got.Failures)
+	}
+	// 只释放成功的那次预占，之前的失败仍然计入IP
+	if got := repo.get("ip:10.0.0.1"); got.Failures != 4 {
+		t.Errorf("ip failures = %d, want 4", got.Failures)
+	}
+}
+
+func TestLoginThrottleIPLimit(t *testing.T) {
+	repo := newFakeLoginThrottleRepository()
+	throttle := newTestLoginThrottle(repo)
+
+	// 每个用户名只失败两次，IP 累计达到上限后锁定所有用户名
+	for i := 0; i < 5; i++ {
+		failLogins(t, throttle, "10.0.0.1", "user"+strconv.Itoa(i), 2)
+	}
+	assertLocked(t, throttle.Reserve("10.0.0.1", "z"), time.Minute)
+	if err := throttle.Reserve("10.0.0.2", "z"); err != nil {
+		t.Fatalf("Reserve from another IP = %v, want allowed", err)
+	}
+}
+
+func TestLoginThrottleConcurrentReserve(t *testing.T) {
+	repo := newFakeLoginThrottleRepository()
+	throttle := newTestLoginThrottle(repo)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := throttle.Reserve("10.0.1."+strconv.Itoa(i), "alice"); err == nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if allowed != 3 {
+		t.Fatalf("allowed %d concurrent attempts, want 3", allowed)
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"weex-watchdog/internal/model"
+	"weex-watchdog/pkg/weex"
+)
+
+// establishBaseline 交易员首次成功拉取时建立基线
+// 已有持仓静默入库（除非交易员开启了 NotifyInitialSnapshot），按配置导入最近的已平仓历史，
+// 之后的拉取只对基线之后的变化发送通知
+func (s *MonitorService) establishBaseline(ctx context.Context, trader model.TraderMonitor, orders []weex.OpenOrder) {
+	// 记录当前持仓
+	s.detectNewOrders(trader.TraderUserID, orders, trader.NotifyInitialSnapshot)
+
+	// 导入最近的已平仓历史
+	imported := 0
+	if s.baselineHistory > 0 {
+		var err error
+		imported, err = s.importClosedHistory(ctx, trader.TraderUserID, time.Now().Add(-s.baselineHistory))
+		if err != nil {
+			// 历史导入失败不影响基线建立，只记录日志
+			s.logger.WithFields(map[string]interface{}{
+				"trader_id": trader.TraderUserID,
+				"error":     err,
+			}).Warn("Failed to import closed history during baseline")
+		}
+	}
+
+	now := time.Now()
+	if err := s.traderRepo.MarkBaselineDone(trader.ID, now); err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"trader_id": trader.TraderUserID,
+			"error":     err,
+		}).Error("Failed to mark trader baseline")
+		return
+	}
+
+	s.logger.WithFields(map[string]interface{}{
+		"trader_id":       trader.TraderUserID,
+		"open_positions":  len(orders),
+		"imported_closed": imported,
+		"notified":        trader.NotifyInitialSnapshot,
+	}).Info("Trader baseline established")
+}
+
+// importClosedHistory 将 since 之后的已平仓历史写入订单记录，已存在的订单跳过
+func (s *MonitorService) importClosedHistory(ctx context.Context, traderUserID string, since time.Time) (int, error) {
+	imported := 0
+	it := weex.NewHistoryIterator(s.weexClient, traderUserID, weex.HistoryQuery{Since: since})
+	for it.Next(ctx) {
+		row := it.Order()
+
+		existing, err := s.orderRepo.GetByTraderAndOrderID(traderUserID, row.OpenOrderID)
+		if err == nil && existing != nil {
+			continue
+		}
+
+		orderHistory := s.buildOrderHistory(traderUserID, row)
+		orderHistory.Status = model.OrderStatusClosed
+		orderHistory.ReconcileStatus = model.ReconcileStatusDone
+		applyCloseDetails(orderHistory, row)
+		orderHistory.ClosedAt = orderHistory.CloseTime
+		if orderHistory.CloseTime != nil {
+			orderHistory.LastSeenAt = *orderHistory.CloseTime
+		}
+
+		if err := s.orderRepo.Create(orderHistory); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"trader_id": traderUserID,
+				"order_id":  row.OpenOrderID,
+				"error":     err,
+			}).Error("Failed to import closed order")
+			continue
+		}
+		imported++
+	}
+
+	return imported, it.Err()
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+
+	"weex-watchdog/internal/model"
+	"weex-watchdog/pkg/weex"
+)
+
+// reconcileClosedOrders 在历史订单中查找已平仓订单，补全平仓价、盈亏、手续费和平仓方式
+// 历史记录尚未出现的订单留到下一次拉取再试，超过最大尝试次数后不再等待
+// 补全（或放弃）的订单统一发送平仓通知
+func (s *MonitorService) reconcileClosedOrders(ctx context.Context, traderUserID string) {
+	pendingOrders, err := s.orderRepo.GetPendingReconcileOrders(traderUserID)
+	if err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"trader_id": traderUserID,
+			"error":     err,
+		}).Error("Failed to get orders pending reconciliation")
+		return
+	}
+	if len(pendingOrders) == 0 {
+		return
+	}
+
+	historyRows, err := s.findHistoryRows(ctx, traderUserID, pendingOrders)
+	if err != nil {
+		// 拉取失败不计入尝试次数，下次拉取时重试
+		s.logger.WithFields(map[string]interface{}{
+			"trader_id": traderUserID,
+			"pending":   len(pendingOrders),
+			"error":     err,
+		}).Warn("Failed to fetch history orders for reconciliation")
+		return
+	}
+
+	readyOrders := make([]*model.OrderHistory, 0, len(pendingOrders))
+	for i := range pendingOrders {
+		order := &pendingOrders[i]
+		order.ReconcileAttempts++
+
+		if row, found := historyRows[order.OrderID]; found {
+			applyCloseDetails(order, row)
+			order.ReconcileStatus = model.ReconcileStatusDone
+		} else if order.ReconcileAttempts >= s.reconcileAttempts {
+			order.ReconcileStatus = model.ReconcileStatusExpired
+			s.logger.WithFields(map[string]interface{}{
+				"trader_id": traderUserID,
+				"order_id":  order.OrderID,
+				"attempts":  order.ReconcileAttempts,
+			}).Warn("History row not found, sending close notification without PnL")
+		}
+
+		if err := s.orderRepo.UpdateCloseDetails(order); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"trader_id": traderUserID,
+				"order_id":  order.OrderID,
+				"error":     err,
+			}).Error("Failed to save close details")
+			continue
+		}
+
+		if order.ReconcileStatus != model.ReconcileStatusPending {
+			readyOrders = append(readyOrders, order)
+			s.logger.WithFields(map[string]interface{}{
+				"trader_id":    traderUserID,
+				"order_id":     order.OrderID,
+				"status":       order.ReconcileStatus,
+				"realized_pnl": order.RealizedPnl,
+				"closed_by":    order.ClosedBy,
+			}).Info("Closed order reconciled")
+		}
+	}
+
+	// 发送平仓通知
+	s.sendCloseOrderNotification(readyOrders)
+}
+
+// findHistoryRows 翻页查找待对账订单对应的历史记录，全部找到后提前结束
+func (s *MonitorService) findHistoryRows(ctx context.Context, traderUserID string, pendingOrders []model.OrderHistory) (map[string]weex.OpenOrder, error) {
+	wanted := make(map[string]bool, len(pendingOrders))
+	since := pendingOrders[0].FirstSeenAt
+	for _, order := range pendingOrders {
+		wanted[order.OrderID] = true
+		if order.FirstSeenAt.Before(since) {
+			since = order.FirstSeenAt
+		}
+	}
+
+	found := make(map[string]weex.OpenOrder, len(pendingOrders))
+	it := weex.NewHistoryIterator(s.weexClient, traderUserID, weex.HistoryQuery{Since: since})
+	for len(found) < len(wanted) && it.Next(ctx) {
+		row := it.Order()
+		if wanted[row.OpenOrderID] {
+			found[row.OpenOrderID] = row
+		}
+	}
+	return found, it.Err()
+}
+
+// applyCloseDetails 将历史记录中的平仓信息写入订单
+func applyCloseDetails(order *model.OrderHistory, row weex.OpenOrder) {
+	order.AverageClosePrice = row.AverageClosePrice
+	order.RealizedPnl = row.RealizedPnl
+	order.CloseFee = row.CloseFee
+	order.FundingFee = row.FundingFee
+	order.ClosedBy = row.ClosedBy
+	if closeTime, ok := weex.ParseMillis(row.CloseTime); ok {
+		order.CloseTime = &closeTime
+	}
+}
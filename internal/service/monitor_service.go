@@ -1,12 +1,14 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
+	"weex-watchdog/internal/config"
 	"weex-watchdog/internal/model"
 	"weex-watchdog/internal/repository"
 	"weex-watchdog/pkg/logger"
@@ -16,49 +18,174 @@ import (
 
 // MonitorService 监控服务
 type MonitorService struct {
-	traderRepo          repository.TraderRepository
-	orderRepo           repository.OrderRepository
-	notificationRepo    repository.NotificationRepository
-	notificationService notification.Client
-	httpClient          *http.Client
-	logger              *logger.Logger
-	apiURL              string
-	traderLastCheck     map[string]time.Time // 记录每个交易员最后检查时间
-	mu                  sync.RWMutex         // 保护 traderLastCheck 的并发访问
+	traderRepo        repository.TraderRepository
+	orderRepo         repository.OrderRepository
+	dispatcher        *NotificationDispatcher
+	subscriptions     *SubscriptionService     // 按订阅将事件路由给交易员的订阅人
+	rules             *NotificationRuleService // 开平仓通知的过滤规则
+	weexClient        weex.API
+	logger            *logger.Logger
+	traderLastCheck   map[string]time.Time // 记录每个交易员最后检查时间
+	traderInFlight    map[string]int64     // 正在拉取的交易员 -> 本次拉取期间跳过的轮次
+	traderSkipped     map[string]int64     // 每个交易员因上次拉取未结束而累计跳过的轮次
+	mu                sync.RWMutex         // 保护以上 map 的并发访问
+	reconcileAttempts int                  // 平仓对账最大尝试次数
+	baselineHistory   time.Duration        // 建立基线时导入多长时间内的已平仓历史，0 表示不导入
+	closeGuard        *closeGuard          // 平仓确认，过滤网关短暂的空响应
+	workers           int                  // 工作协程数
+	jobs              chan monitorJob      // 待拉取任务队列
+	stats             monitorStats         // 工作池运行统计
+	workCtx           context.Context      // 拉取任务使用的上下文，排空超时后取消
+	cancelWork        context.CancelFunc
+	workerWg          sync.WaitGroup
+	stopping          atomic.Bool   // 已停止调度，队列中剩余任务直接丢弃
+	stopped           chan struct{} // 调度循环退出后关闭
+}
+
+// monitorJob 单个交易员的拉取任务
+type monitorJob struct {
+	trader     model.TraderMonitor
+	enqueuedAt time.Time
+}
+
+// MonitorStats 监控工作池状态
+type MonitorStats struct {
+	Workers       int     `json:"workers"`
+	BusyWorkers   int     `json:"busy_workers"`
+	QueueDepth    int     `json:"queue_depth"`
+	QueueCapacity int     `json:"queue_capacity"`
+	Processed     int64   `json:"processed"`
+	Dropped       int64   `json:"dropped"`
+	InFlight      int     `json:"in_flight"`
+	SkippedTicks  int64   `json:"skipped_ticks"`
+	PendingCloses int     `json:"pending_closes"`
+	Suspicious    int64   `json:"suspicious_polls"`
+	AvgWaitMs     float64 `json:"avg_wait_ms"`
+	MaxWaitMs     float64 `json:"max_wait_ms"`
+	LastWaitMs    float64 `json:"last_wait_ms"`
+
+	SkippedByTrader map[string]int64 `json:"skipped_by_trader"`
+}
+
+// monitorStats 工作池运行统计
+type monitorStats struct {
+	mu        sync.Mutex
+	busy      int
+	started   int64 // 已开始的拉取数，与 totalWait 同时累加，用于计算平均等待
+	processed int64
+	dropped   int64
+	totalWait time.Duration
+	maxWait   time.Duration
+	lastWait  time.Duration
 }
 
 // NewMonitorService 创建监控服务
 func NewMonitorService(
 	traderRepo repository.TraderRepository,
 	orderRepo repository.OrderRepository,
-	notificationRepo repository.NotificationRepository,
-	notificationService notification.Client,
+	dispatcher *NotificationDispatcher,
+	subscriptions *SubscriptionService,
+	rules *NotificationRuleService,
+	weexClient weex.API,
+	monitorConfig config.MonitorConfig,
 	logger *logger.Logger,
-	apiURL string,
 ) *MonitorService {
+	workers := monitorConfig.MaxGoroutines
+	if workers <= 0 {
+		workers = 100
+	}
+	queueSize := monitorConfig.QueueSize
+	if queueSize <= 0 {
+		queueSize = 1000
+	}
+	reconcileAttempts := monitorConfig.ReconcileMaxAttempts
+	if reconcileAttempts <= 0 {
+		reconcileAttempts = 10
+	}
+
+	closeGuard := newCloseGuard(
+		monitorConfig.CloseConfirmPolls,
+		monitorConfig.CloseGracePeriod,
+		monitorConfig.SuspiciousMinOrders,
+		monitorConfig.SuspiciousConfirmPolls,
+	)
+
+	workCtx, cancelWork := context.WithCancel(context.Background())
+
 	return &MonitorService{
-		traderRepo:          traderRepo,
-		orderRepo:           orderRepo,
-		notificationRepo:    notificationRepo,
-		notificationService: notificationService,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		logger:          logger,
-		apiURL:          apiURL,
-		traderLastCheck: make(map[string]time.Time),
+		traderRepo:        traderRepo,
+		orderRepo:         orderRepo,
+		dispatcher:        dispatcher,
+		subscriptions:     subscriptions,
+		rules:             rules,
+		weexClient:        weexClient,
+		logger:            logger,
+		traderLastCheck:   make(map[string]time.Time),
+		traderInFlight:    make(map[string]int64),
+		traderSkipped:     make(map[string]int64),
+		reconcileAttempts: reconcileAttempts,
+		baselineHistory:   monitorConfig.BaselineHistoryWindow,
+		closeGuard:        closeGuard,
+		workers:           workers,
+		jobs:              make(chan monitorJob, queueSize),
+		workCtx:           workCtx,
+		cancelWork:        cancelWork,
+		stopped:           make(chan struct{}),
 	}
 }
 
-// StartMonitoring 启动监控
-func (s *MonitorService) StartMonitoring() {
-	s.logger.Logger.Info("Starting monitoring service")
+// StartMonitoring 启动监控，阻塞直到 ctx 被取消
+// ctx 取消后不再调度新的拉取，正在进行的拉取由 Shutdown 负责等待
+func (s *MonitorService) StartMonitoring(ctx context.Context) {
+	s.logger.WithFields(map[string]interface{}{
+		"workers":    s.workers,
+		"queue_size": cap(s.jobs),
+	}).Info("Starting monitoring service")
+
+	s.workerWg.Add(s.workers)
+	for i := 0; i < s.workers; i++ {
+		go s.worker()
+	}
 
 	ticker := time.NewTicker(1 * time.Second) // 改为1秒间隔
 	defer ticker.Stop()
 
-	for range ticker.C {
-		s.monitorAllTraders()
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Monitoring scheduler stopped")
+			s.stopping.Store(true)
+			close(s.jobs)
+			close(s.stopped)
+			return
+		case <-ticker.C:
+			s.monitorAllTraders()
+		}
+	}
+}
+
+// Shutdown 等待正在进行的拉取和通知发送完成
+// ctx 到期时取消所有未完成的拉取并返回 ctx.Err()
+func (s *MonitorService) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		<-s.stopped
+		s.workerWg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.cancelWork()
+		s.logger.Info("Monitoring service drained")
+		return nil
+	case <-ctx.Done():
+		s.cancelWork()
+		s.mu.RLock()
+		inFlight := len(s.traderInFlight)
+		s.mu.RUnlock()
+		s.logger.WithField("in_flight", inFlight).Warn("Monitoring drain timed out, cancelling in-flight polls")
+		return ctx.Err()
 	}
 }
 
@@ -70,43 +197,182 @@ func (s *MonitorService) monitorAllTraders() {
 		return
 	}
 
+	// 清除已停用或删除的交易员的平仓确认状态
+	activeTraders := make(map[string]bool, len(traders))
+	for _, trader := range traders {
+		activeTraders[trader.TraderUserID] = true
+	}
+	s.closeGuard.retain(activeTraders)
+
 	now := time.Now()
+	dropped := 0
 
 	for _, trader := range traders {
 		// 检查是否需要监控这个交易员
-		if s.shouldMonitorTrader(trader, now) {
-			go s.monitorSingleTrader(trader, now)
+		if s.shouldMonitorTrader(trader, now) && !s.enqueue(trader, now) {
+			dropped++
 		}
 	}
+
+	if dropped > 0 {
+		s.logger.WithFields(map[string]interface{}{
+			"dropped":     dropped,
+			"queue_depth": len(s.jobs),
+		}).Warn("Monitor queue is full, traders will be retried on next tick")
+	}
+}
+
+// enqueue 将交易员放入拉取队列，队列已满时返回 false
+func (s *MonitorService) enqueue(trader model.TraderMonitor, now time.Time) bool {
+	select {
+	case s.jobs <- monitorJob{trader: trader, enqueuedAt: now}:
+		return true
+	default:
+		// 未能入队，清除检查时间和拉取标记以便下一轮重新调度
+		s.mu.Lock()
+		delete(s.traderLastCheck, trader.TraderUserID)
+		delete(s.traderInFlight, trader.TraderUserID)
+		s.mu.Unlock()
+
+		s.stats.mu.Lock()
+		s.stats.dropped++
+		s.stats.mu.Unlock()
+		return false
+	}
+}
+
+// worker 工作协程，从队列中取任务执行
+func (s *MonitorService) worker() {
+	defer s.workerWg.Done()
+
+	for job := range s.jobs {
+		// 停止调度后不再开始新的拉取
+		if s.stopping.Load() {
+			s.finishTrader(job.trader)
+			continue
+		}
+
+		wait := time.Since(job.enqueuedAt)
+
+		s.stats.mu.Lock()
+		s.stats.busy++
+		s.stats.lastWait = wait
+		s.stats.started++
+		s.stats.totalWait += wait
+		if wait > s.stats.maxWait {
+			s.stats.maxWait = wait
+		}
+		s.stats.mu.Unlock()
+
+		s.monitorSingleTrader(s.workCtx, job.trader, job.enqueuedAt)
+		s.finishTrader(job.trader)
+
+		s.stats.mu.Lock()
+		s.stats.busy--
+		s.stats.processed++
+		s.stats.mu.Unlock()
+	}
+}
+
+// finishTrader 清除交易员的拉取标记，本次拉取跨越了多个监控周期时记录告警
+func (s *MonitorService) finishTrader(trader model.TraderMonitor) {
+	s.mu.Lock()
+	skipped := s.traderInFlight[trader.TraderUserID]
+	delete(s.traderInFlight, trader.TraderUserID)
+	s.mu.Unlock()
+
+	if skipped > 0 {
+		s.logger.WithFields(map[string]interface{}{
+			"trader_id": trader.TraderUserID,
+			"interval":  trader.MonitorInterval,
+			"skipped":   skipped,
+		}).Warn("Trader poll took longer than monitor interval, ticks skipped")
+	}
+}
+
+// GetStats 获取工作池状态
+func (s *MonitorService) GetStats() MonitorStats {
+	s.mu.RLock()
+	inFlight := len(s.traderInFlight)
+	skippedByTrader := make(map[string]int64, len(s.traderSkipped))
+	var skippedTicks int64
+	for traderID, skipped := range s.traderSkipped {
+		skippedByTrader[traderID] = skipped
+		skippedTicks += skipped
+	}
+	s.mu.RUnlock()
+
+	pendingCloses, suspiciousPolls := s.closeGuard.stats()
+
+	s.stats.mu.Lock()
+	defer s.stats.mu.Unlock()
+
+	stats := MonitorStats{
+		Workers:       s.workers,
+		BusyWorkers:   s.stats.busy,
+		QueueDepth:    len(s.jobs),
+		QueueCapacity: cap(s.jobs),
+		Processed:     s.stats.processed,
+		Dropped:       s.stats.dropped,
+		InFlight:      inFlight,
+		SkippedTicks:  skippedTicks,
+		PendingCloses: pendingCloses,
+		Suspicious:    suspiciousPolls,
+		MaxWaitMs:     durationMs(s.stats.maxWait),
+		LastWaitMs:    durationMs(s.stats.lastWait),
+
+		SkippedByTrader: skippedByTrader,
+	}
+	if s.stats.started > 0 {
+		stats.AvgWaitMs = durationMs(s.stats.totalWait) / float64(s.stats.started)
+	}
+	return stats
+}
+
+// durationMs 将时长转换为毫秒
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
 }
 
 // shouldMonitorTrader 判断是否应该监控某个交易员
+// 同一交易员同时最多只有一个拉取在进行，上一次未结束时本轮跳过并计数
 func (s *MonitorService) shouldMonitorTrader(trader model.TraderMonitor, now time.Time) bool {
-	s.mu.RLock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 距离上次检查未超过设定的间隔
 	lastCheck, exists := s.traderLastCheck[trader.TraderUserID]
-	s.mu.RUnlock()
+	if exists && now.Sub(lastCheck) < time.Duration(trader.MonitorInterval)*time.Second {
+		return false
+	}
 
-	// 如果从未检查过，或者距离上次检查已经超过了设定的间隔
-	if !exists || now.Sub(lastCheck) >= time.Duration(trader.MonitorInterval)*time.Second {
-		// 更新最后检查时间
-		s.mu.Lock()
-		s.traderLastCheck[trader.TraderUserID] = now
-		s.mu.Unlock()
-		return true
+	// 更新最后检查时间，跳过的轮次也按间隔计数
+	s.traderLastCheck[trader.TraderUserID] = now
+
+	if skipped, running := s.traderInFlight[trader.TraderUserID]; running {
+		s.traderInFlight[trader.TraderUserID] = skipped + 1
+		s.traderSkipped[trader.TraderUserID]++
+		s.logger.WithFields(map[string]interface{}{
+			"trader_id": trader.TraderUserID,
+			"skipped":   skipped + 1,
+		}).Debug("Previous poll still in flight, skipping tick")
+		return false
 	}
 
-	return false
+	s.traderInFlight[trader.TraderUserID] = 0
+	return true
 }
 
 // monitorSingleTrader 监控单个交易员
-func (s *MonitorService) monitorSingleTrader(trader model.TraderMonitor, _ time.Time) {
+func (s *MonitorService) monitorSingleTrader(ctx context.Context, trader model.TraderMonitor, scheduledAt time.Time) {
 	s.logger.WithFields(map[string]interface{}{
-		"trader_id": trader.TraderUserID,
-		"interval":  trader.MonitorInterval,
+		"trader_id":  trader.TraderUserID,
+		"interval":   trader.MonitorInterval,
+		"queue_wait": time.Since(scheduledAt).String(),
 	}).Debug("Monitoring trader")
 
 	// 获取当前订单
-	orders, err := s.fetchTraderOrders(trader.TraderUserID)
+	orders, err := s.fetchTraderOrders(ctx, trader.TraderUserID)
 	if err != nil {
 		s.logger.WithFields(map[string]interface{}{
 			"trader_id": trader.TraderUserID,
@@ -115,23 +381,34 @@ func (s *MonitorService) monitorSingleTrader(trader model.TraderMonitor, _ time.
 		return
 	}
 
+	// 首次成功拉取：建立基线，不把已有持仓当作新开仓
+	if trader.BaselineAt == nil {
+		s.establishBaseline(ctx, trader, orders)
+		return
+	}
+
 	// 检测新订单
-	s.detectNewOrders(trader.TraderUserID, orders)
+	s.detectNewOrders(trader.TraderUserID, orders, true)
+
+	// 检测持仓变化（止盈止损、加减仓、保证金）
+	s.detectOrderChanges(trader.TraderUserID, orders)
 
 	// 检测平仓订单
 	s.detectClosedOrders(trader.TraderUserID, orders)
+
+	// 补全平仓盈亏并发送平仓通知
+	s.reconcileClosedOrders(ctx, trader.TraderUserID)
 }
 
 // fetchTraderOrders 获取交易员订单
-func (s *MonitorService) fetchTraderOrders(traderUserID string) ([]weex.OpenOrder, error) {
+func (s *MonitorService) fetchTraderOrders(ctx context.Context, traderUserID string) ([]weex.OpenOrder, error) {
 	// 将 traderUserID 转换为 uint
 	traderID, err := strconv.ParseUint(traderUserID, 10, 64)
 	if err != nil {
 		return nil, fmt.Errorf("invalid trader user ID: %w", err)
 	}
 
-	// 使用新的 GetOpenOrderList 函数
-	orders, err := weex.GetOpenOrderList(uint(traderID))
+	orders, err := s.weexClient.GetOpenOrderList(ctx, uint(traderID))
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch orders: %w", err)
 	}
@@ -139,44 +416,15 @@ func (s *MonitorService) fetchTraderOrders(traderUserID string) ([]weex.OpenOrde
 	return orders, nil
 }
 
-// detectNewOrders 检测新订单
-func (s *MonitorService) detectNewOrders(traderUserID string, currentOrders []weex.OpenOrder) {
+// detectNewOrders 检测新订单，notify 为 false 时只记录不通知
+func (s *MonitorService) detectNewOrders(traderUserID string, currentOrders []weex.OpenOrder, notify bool) {
 	newOrders := make([]*model.OrderHistory, 0)
 	for _, order := range currentOrders {
 		// 检查订单是否已存在
 		existing, err := s.orderRepo.GetByTraderAndOrderID(traderUserID, order.OpenOrderID)
 		if err != nil || existing == nil {
 			// 新订单
-			// 解析创建时间字符串为时间戳
-			openTime, err := strconv.ParseInt(order.OpenTime, 10, 64)
-			if err != nil {
-				s.logger.WithFields(map[string]interface{}{
-					"trader_id":    traderUserID,
-					"order_id":     order.OpenOrderID,
-					"created_time": order.CreatedTime,
-					"error":        err,
-				}).Error("Failed to parse created time")
-				openTime = time.Now().UnixMilli() // 使用当前时间作为fallback
-			}
-
-			// 获取交易对名称
-			contractMapper := weex.GetContractMapper()
-			symbolName := contractMapper.GetSymbolName(order.ContractID)
-
-			orderHistory := &model.OrderHistory{
-				TraderUserID:   traderUserID,
-				TraderName:     order.TraderName,
-				OrderID:        order.OpenOrderID,
-				OrderData:      s.convertToJSON(order),
-				ContractSymbol: symbolName,
-				Status:         model.OrderStatusActive,
-				PositionSide:   order.PositionSide,
-				OpenSize:       order.OpenSize,
-				OpenPrice:      order.AverageOpenPrice,
-				OpenLeverage:   order.OpenLeverage + "x",
-				FirstSeenAt:    time.UnixMilli(openTime),
-				LastSeenAt:     time.Now(),
-			}
+			orderHistory := s.buildOrderHistory(traderUserID, order)
 
 			if err := s.orderRepo.Create(orderHistory); err != nil {
 				s.logger.WithFields(map[string]interface{}{
@@ -197,12 +445,47 @@ func (s *MonitorService) detectNewOrders(traderUserID string, currentOrders []we
 		}
 	}
 	// 统一发送开仓通知
-	s.sendNewOrderNotification(newOrders)
+	if notify {
+		s.sendNewOrderNotification(newOrders)
+	}
 }
 
-// detectClosedOrders 检测平仓订单
-func (s *MonitorService) detectClosedOrders(traderUserID string, currentOrders []weex.OpenOrder) {
-	// 获取数据库中的活跃订单
+// buildOrderHistory 根据网关订单构建订单记录
+func (s *MonitorService) buildOrderHistory(traderUserID string, order weex.OpenOrder) *model.OrderHistory {
+	// 解析创建时间字符串为时间戳
+	openTime, err := strconv.ParseInt(order.OpenTime, 10, 64)
+	if err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"trader_id":    traderUserID,
+			"order_id":     order.OpenOrderID,
+			"created_time": order.CreatedTime,
+			"error":        err,
+		}).Error("Failed to parse created time")
+		openTime = time.Now().UnixMilli() // 使用当前时间作为fallback
+	}
+
+	// 获取交易对名称
+	contractMapper := weex.GetContractMapper()
+	symbolName := contractMapper.GetSymbolName(order.ContractID)
+
+	return &model.OrderHistory{
+		TraderUserID:   traderUserID,
+		TraderName:     order.TraderName,
+		OrderID:        order.OpenOrderID,
+		OrderData:      s.convertToJSON(order),
+		ContractSymbol: symbolName,
+		Status:         model.OrderStatusActive,
+		PositionSide:   order.PositionSide,
+		OpenSize:       order.OpenSize,
+		OpenPrice:      order.AverageOpenPrice,
+		OpenLeverage:   order.OpenLeverage + "x",
+		FirstSeenAt:    time.UnixMilli(openTime),
+		LastSeenAt:     time.Now(),
+	}
+}
+
+// detectOrderChanges 对比仍在持仓的订单与数据库快照，检测持仓变化并刷新最后出现时间
+func (s *MonitorService) detectOrderChanges(traderUserID string, currentOrders []weex.OpenOrder) {
 	activeOrders, err := s.orderRepo.GetActiveOrdersByTrader(traderUserID)
 	if err != nil {
 		s.logger.WithFields(map[string]interface{}{
@@ -212,128 +495,248 @@ func (s *MonitorService) detectClosedOrders(traderUserID string, currentOrders [
 		return
 	}
 
-	// 创建当前订单ID映射
-	currentOrderIDs := make(map[string]bool)
-	for _, order := range currentOrders {
-		currentOrderIDs[order.OpenOrderID] = true
+	storedOrders := make(map[string]*model.OrderHistory, len(activeOrders))
+	for i := range activeOrders {
+		storedOrders[activeOrders[i].OrderID] = &activeOrders[i]
 	}
 
-	closedOrders := make([]*model.OrderHistory, 0)
-	// 检查哪些活跃订单在当前订单中不存在（已平仓）
-	for _, activeOrder := range activeOrders {
-		if !currentOrderIDs[activeOrder.OrderID] {
-			// 订单已平仓
-			now := time.Now()
-			if err := s.orderRepo.UpdateOrderStatus(activeOrder.ID, model.OrderStatusClosed, &now); err != nil {
-				s.logger.WithFields(map[string]interface{}{
-					"trader_id": traderUserID,
-					"order_id":  activeOrder.OrderID,
-					"error":     err,
-				}).Error("Failed to update order status")
-				continue
-			}
+	now := time.Now()
+	unchangedIDs := make([]uint, 0, len(currentOrders))
+	changes := make([]*model.OrderChangeEvent, 0)
 
-			// 更新本地对象用于发送通知
-			activeOrder.Status = model.OrderStatusClosed
-			activeOrder.ClosedAt = &now
+	for _, order := range currentOrders {
+		stored, exists := storedOrders[order.OpenOrderID]
+		if !exists {
+			continue
+		}
 
-			closedOrders = append(closedOrders, &activeOrder)
+		previous, ok := snapshotToOrder(stored.OrderData)
+		var events []*model.OrderChangeEvent
+		if ok {
+			events = diffOrder(stored, previous, order, now)
+		}
+		if len(events) == 0 && ok {
+			unchangedIDs = append(unchangedIDs, stored.ID)
+			continue
+		}
 
+		// 快照有变化（或缺失），保存最新快照
+		stored.OrderData = s.convertToJSON(order)
+		stored.OpenSize = order.OpenSize
+		stored.OpenPrice = order.AverageOpenPrice
+		stored.LastSeenAt = now
+		if err := s.orderRepo.UpdateSnapshot(stored); err != nil {
 			s.logger.WithFields(map[string]interface{}{
 				"trader_id": traderUserID,
-				"order_id":  activeOrder.OrderID,
-			}).Info("Order closed detected")
+				"order_id":  order.OpenOrderID,
+				"error":     err,
+			}).Error("Failed to update order snapshot")
+			continue
+		}
+
+		for _, event := range events {
+			if err := s.orderRepo.CreateChangeEvent(event); err != nil {
+				s.logger.WithFields(map[string]interface{}{
+					"trader_id":   traderUserID,
+					"order_id":    order.OpenOrderID,
+					"change_type": event.ChangeType,
+					"error":       err,
+				}).Error("Failed to save order change event")
+				continue
+			}
+			changes = append(changes, event)
+
+			s.logger.WithFields(map[string]interface{}{
+				"trader_id":   traderUserID,
+				"order_id":    order.OpenOrderID,
+				"change_type": event.ChangeType,
+				"old_value":   event.OldValue,
+				"new_value":   event.NewValue,
+			}).Info("Order change detected")
 		}
 	}
 
-	// 发送平仓通知
-	s.sendCloseOrderNotification(closedOrders)
+	if err := s.orderRepo.TouchLastSeen(unchangedIDs, now); err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"trader_id": traderUserID,
+			"error":     err,
+		}).Error("Failed to refresh order last seen time")
+	}
 
+	// 发送持仓变化通知
+	s.sendOrderChangeNotification(changes)
 }
 
-// sendNewOrderNotification 发送新订单通知
-func (s *MonitorService) sendNewOrderNotification(newOrders []*model.OrderHistory) {
-	if len(newOrders) == 0 {
+// detectClosedOrders 检测平仓订单
+func (s *MonitorService) detectClosedOrders(traderUserID string, currentOrders []weex.OpenOrder) {
+	// 获取数据库中的活跃订单
+	activeOrders, err := s.orderRepo.GetActiveOrdersByTrader(traderUserID)
+	if err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"trader_id": traderUserID,
+			"error":     err,
+		}).Error("Failed to get active orders")
 		return
 	}
 
-	notificationIds := make([]uint, 0)
-	for _, order := range newOrders {
-		// 记录通知日志
-		notificationLog := &model.NotificationLog{
-			TraderUserID:     order.TraderUserID,
-			OrderID:          order.OrderID,
-			NotificationType: model.NotificationTypeNewOrder,
-			Status:           model.NotificationStatusPending,
-			SentAt:           time.Now(),
+	// 创建当前订单ID映射
+	currentOrderIDs := make(map[string]bool)
+	for _, order := range currentOrders {
+		currentOrderIDs[order.OpenOrderID] = true
+	}
+
+	// 缺失的订单需要多次确认才判定为平仓
+	confirmedOrders, pending, suspicious := s.closeGuard.observe(traderUserID, activeOrders, currentOrderIDs, time.Now())
+	if suspicious {
+		s.logger.WithFields(map[string]interface{}{
+			"trader_id":     traderUserID,
+			"active_orders": len(activeOrders),
+		}).Warn("Suspicious poll: all active positions disappeared at once, waiting for more confirmations")
+	}
+	if pending > 0 {
+		s.logger.WithFields(map[string]interface{}{
+			"trader_id": traderUserID,
+			"pending":   pending,
+		}).Debug("Orders missing from response, waiting for close confirmation")
+	}
+
+	for _, activeOrder := range confirmedOrders {
+		// 订单已平仓
+		now := time.Now()
+		if err := s.orderRepo.UpdateOrderStatus(activeOrder.ID, model.OrderStatusClosed, &now); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"trader_id": traderUserID,
+				"order_id":  activeOrder.OrderID,
+				"error":     err,
+			}).Error("Failed to update order status")
+			continue
 		}
 
-		if err := s.notificationRepo.Create(notificationLog); err != nil {
-			s.logger.WithField("error", err).Error("Failed to create notification log")
-			return
+		// 标记为待对账，平仓通知在补全盈亏后发送
+		activeOrder.ReconcileStatus = model.ReconcileStatusPending
+		activeOrder.ReconcileAttempts = 0
+		if err := s.orderRepo.UpdateCloseDetails(&activeOrder); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"trader_id": traderUserID,
+				"order_id":  activeOrder.OrderID,
+				"error":     err,
+			}).Error("Failed to mark order for reconciliation")
 		}
 
-		notificationIds = append(notificationIds, notificationLog.ID)
+		s.logger.WithFields(map[string]interface{}{
+			"trader_id": traderUserID,
+			"order_id":  activeOrder.OrderID,
+		}).Info("Order closed detected")
 	}
+}
 
-	// 构建通知消息
-	message := s.notificationService.BuildNotificationMessage(newOrders, true)
-
-	// 发送通知
-	notificationMsg := &notification.NotificationMessage{
-		Type:    string(model.NotificationTypeNewOrder),
-		Message: message,
+// sendNewOrderNotification 发送新订单通知
+func (s *MonitorService) sendNewOrderNotification(newOrders []*model.OrderHistory) {
+	newOrders = s.applyRules(model.NotificationTypeNewOrder, newOrders)
+	if len(newOrders) == 0 {
+		return
 	}
 
-	if err := s.notificationService.SendMessage(*notificationMsg); err != nil {
-		s.logger.WithField("error", err).Error("Failed to send new order notification")
-		s.notificationRepo.UpdateStatusBatch(notificationIds, model.NotificationStatusFailed, err.Error())
-	} else {
-		s.notificationRepo.UpdateStatusBatch(notificationIds, model.NotificationStatusSuccess, "")
-	}
+	s.dispatch(NotificationEvent{
+		Type:         model.NotificationTypeNewOrder,
+		TraderUserID: newOrders[0].TraderUserID,
+		OrderIDs:     orderIDs(newOrders),
+		Data:         newOrders,
+		Render: func(client notification.Client) string {
+			return client.BuildNotificationMessage(newOrders, true)
+		},
+	})
 }
 
 // sendCloseOrderNotification 发送平仓通知
 func (s *MonitorService) sendCloseOrderNotification(closedOrders []*model.OrderHistory) {
+	closedOrders = s.applyRules(model.NotificationTypeOrderClosed, closedOrders)
 	if len(closedOrders) == 0 {
 		return
 	}
 
-	notificationIds := make([]uint, 0)
+	s.dispatch(NotificationEvent{
+		Type:         model.NotificationTypeOrderClosed,
+		TraderUserID: closedOrders[0].TraderUserID,
+		OrderIDs:     orderIDs(closedOrders),
+		Data:         closedOrders,
+		Render: func(client notification.Client) string {
+			return client.BuildNotificationMessage(closedOrders, false)
+		},
+	})
+}
 
-	for _, order := range closedOrders {
-		// 记录通知日志
-		notificationLog := &model.NotificationLog{
-			TraderUserID:     order.TraderUserID,
-			OrderID:          order.OrderID,
-			NotificationType: model.NotificationTypeOrderClosed,
-			Status:           model.NotificationStatusPending,
-			SentAt:           time.Now(),
-		}
+// sendOrderChangeNotification 发送持仓变化通知
+func (s *MonitorService) sendOrderChangeNotification(changes []*model.OrderChangeEvent) {
+	if len(changes) == 0 {
+		return
+	}
 
-		if err := s.notificationRepo.Create(notificationLog); err != nil {
-			s.logger.WithField("error", err).Error("Failed to create notification log")
-			continue
-		}
+	ids := make([]string, 0, len(changes))
+	for _, change := range changes {
+		ids = append(ids, change.OrderID)
+	}
+
+	s.dispatch(NotificationEvent{
+		Type:         model.NotificationTypeOrderUpdated,
+		TraderUserID: changes[0].TraderUserID,
+		OrderIDs:     ids,
+		Data:         changes,
+		Render: func(client notification.Client) string {
+			return client.BuildOrderChangeMessage(changes)
+		},
+	})
+}
 
-		notificationIds = append(notificationIds, notificationLog.ID)
+// applyRules 按过滤规则筛选需要通知的订单，被拦截的订单记为 FILTERED
+func (s *MonitorService) applyRules(notificationType model.NotificationType, orders []*model.OrderHistory) []*model.OrderHistory {
+	if len(orders) == 0 {
+		return orders
 	}
 
-	// 构建通知消息
-	message := s.notificationService.BuildNotificationMessage(closedOrders, false)
+	passed, filtered := s.rules.Filter(orders[0].TraderUserID, orders, time.Now())
+	if len(filtered) > 0 {
+		s.dispatcher.RecordFiltered(notificationType, filtered)
+		s.logger.WithFields(map[string]interface{}{
+			"trader_id": orders[0].TraderUserID,
+			"type":      notificationType,
+			"filtered":  len(filtered),
+			"passed":    len(passed),
+		}).Info("Notifications filtered by rules")
+	}
+	return passed
+}
 
-	// 发送通知
-	notificationMsg := &notification.NotificationMessage{
-		Type:    string(model.NotificationTypeOrderClosed),
-		Message: message,
+// dispatch 按订阅路由并分发事件：交易员有订阅时只发送给订阅了该事件的订阅人，没有任何订阅时发送到渠道默认接收人
+func (s *MonitorService) dispatch(event NotificationEvent) {
+	recipients, subscribed, err := s.subscriptions.Recipients(event.TraderUserID, event.Type)
+	if err != nil {
+		// 查询订阅失败时退回默认接收人，避免丢失通知
+		s.logger.WithFields(map[string]interface{}{
+			"trader_id": event.TraderUserID,
+			"error":     err,
+		}).Error("Failed to load notification subscribers")
+	} else if subscribed {
+		if len(recipients) == 0 {
+			s.logger.WithFields(map[string]interface{}{
+				"trader_id": event.TraderUserID,
+				"type":      event.Type,
+			}).Debug("No subscriber for notification event")
+			return
+		}
+		event.Recipients = recipients
 	}
 
-	if err := s.notificationService.SendMessage(*notificationMsg); err != nil {
-		s.notificationRepo.UpdateStatusBatch(notificationIds, model.NotificationStatusFailed, err.Error())
-		s.logger.WithField("error", err).Error("Failed to send close order notification")
-	} else {
-		s.notificationRepo.UpdateStatusBatch(notificationIds, model.NotificationStatusSuccess, "")
+	s.dispatcher.Dispatch(event)
+}
+
+// orderIDs 订单ID列表
+func orderIDs(orders []*model.OrderHistory) []string {
+	ids := make([]string, 0, len(orders))
+	for _, order := range orders {
+		ids = append(ids, order.OrderID)
 	}
+	return ids
 }
 
 // convertToJSON 转换为JSON
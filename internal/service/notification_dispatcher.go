@@ -0,0 +1,386 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"weex-watchdog/internal/model"
+	"weex-watchdog/internal/repository"
+	"weex-watchdog/pkg/logger"
+	"weex-watchdog/pkg/notification"
+)
+
+// NotificationEvent 待分发的通知事件
+type NotificationEvent struct {
+	Type         model.NotificationType
+	TraderUserID string
+	OrderIDs     []string                                // 事件涉及的订单，每个渠道为每个订单记录一条通知日志
+	Data         interface{}                             // 结构化数据，随消息传给渠道
+	Render       func(client notification.Client) string // 按渠道生成消息内容
+	Recipients   []Recipient                             // 订阅人，为空时发送到各渠道配置的默认接收人
+}
+
+// Recipient 订阅人在某个渠道的接收地址
+type Recipient struct {
+	SubscriberID uint
+	Channel      string
+	Address      string
+}
+
+// ChannelResult 单个渠道（或订阅人）的发送结果
+type ChannelResult struct {
+	Channel   string
+	Recipient string // 订阅人地址，发送到默认接收人时为空
+	Err       error
+}
+
+// pendingRetryDelay 通知写入后到首次发送完成前的保护时间，进程在发送中途退出时由重试任务接管
+const pendingRetryDelay = 5 * time.Minute
+
+// NotificationDispatcher 多渠道通知分发器，每条消息并发发送到所有渠道
+// 消息在发送前连同渲染结果写入通知日志，发送失败的由重试任务按退避策略重发
+type NotificationDispatcher struct {
+	notificationRepo repository.NotificationRepository
+	channels         []notification.Channel
+	retry            notification.RetryConfig
+	retryMu          sync.Mutex // 避免重试任务和手动重发同时发送同一批通知
+	logger           *logger.Logger
+}
+
+// NewNotificationDispatcher 创建通知分发器
+func NewNotificationDispatcher(notificationRepo repository.NotificationRepository, channels []notification.Channel, retry notification.RetryConfig, logger *logger.Logger) *NotificationDispatcher {
+	if retry.MaxAttempts <= 0 {
+		retry.MaxAttempts = 5
+	}
+	if retry.Interval <= 0 {
+		retry.Interval = 30 * time.Second
+	}
+	if retry.BaseDelay <= 0 {
+		retry.BaseDelay = 30 * time.Second
+	}
+	if retry.MaxDelay < retry.BaseDelay {
+		retry.MaxDelay = 30 * time.Minute
+	}
+	if retry.BatchSize <= 0 {
+		retry.BatchSize = 50
+	}
+
+	return &NotificationDispatcher{
+		notificationRepo: notificationRepo,
+		channels:         channels,
+		retry:            retry,
+		logger:           logger,
+	}
+}
+
+// ChannelNames 已启用的渠道名称
+func (d *NotificationDispatcher) ChannelNames() []string {
+	names := make([]string, 0, len(d.channels))
+	for _, channel := range d.channels {
+		names = append(names, channel.Name)
+	}
+	return names
+}
+
+// Channel 按名称获取渠道
+func (d *NotificationDispatcher) Channel(name string) (notification.Channel, bool) {
+	for _, channel := range d.channels {
+		if channel.Name == name {
+			return channel, true
+		}
+	}
+	return notification.Channel{}, false
+}
+
+// dispatchTarget 一个渠道及其接收人，recipients 中的 nil 表示渠道默认接收人
+type dispatchTarget struct {
+	channel    notification.Channel
+	recipients []*Recipient
+}
+
+// Dispatch 将事件发送到所有渠道（或事件指定的订阅人）并记录每个接收人的发送状态，单个渠道失败不影响其他渠道
+func (d *NotificationDispatcher) Dispatch(event NotificationEvent) []ChannelResult {
+	targets, results := d.resolveTargets(event)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target dispatchTarget) {
+			defer wg.Done()
+			channelResults := d.sendToChannel(target, event)
+			mu.Lock()
+			results = append(results, channelResults...)
+			mu.Unlock()
+		}(target)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// resolveTargets 按渠道分组接收人，渠道未启用的订阅人直接记为 DEAD 并返回失败结果
+func (d *NotificationDispatcher) resolveTargets(event NotificationEvent) ([]dispatchTarget, []ChannelResult) {
+	recipients := event.Recipients
+	if len(recipients) == 0 {
+		targets := make([]dispatchTarget, 0, len(d.channels))
+		for _, channel := range d.channels {
+			targets = append(targets, dispatchTarget{channel: channel, recipients: []*Recipient{nil}})
+		}
+		return targets, nil
+	}
+
+	var targets []dispatchTarget
+	var results []ChannelResult
+	index := make(map[string]int)
+	for i := range recipients {
+		recipient := &recipients[i]
+		if idx, ok := index[recipient.Channel]; ok {
+			targets[idx].recipients = append(targets[idx].recipients, recipient)
+			continue
+		}
+		channel, ok := d.Channel(recipient.Channel)
+		if !ok {
+			err := fmt.Errorf("notification channel %q is not enabled", recipient.Channel)
+			d.recordUndeliverable(event, recipient, err)
+			results = append(results, ChannelResult{
+				Channel:   recipient.Channel,
+				Recipient: recipient.Address,
+				Err:       err,
+			})
+			continue
+		}
+		index[recipient.Channel] = len(targets)
+		targets = append(targets, dispatchTarget{channel: channel, recipients: []*Recipient{recipient}})
+	}
+	return targets, results
+}
+
+// recordUndeliverable 记录无法发送给订阅人的通知
+func (d *NotificationDispatcher) recordUndeliverable(event NotificationEvent, recipient *Recipient, reason error) {
+	for _, orderID := range event.OrderIDs {
+		notificationLog := &model.NotificationLog{
+			TraderUserID:     event.TraderUserID,
+			OrderID:          orderID,
+			Channel:          recipient.Channel,
+			NotificationType: event.Type,
+			SubscriberID:     &recipient.SubscriberID,
+			Recipient:        recipient.Address,
+			Status:           model.NotificationStatusDead,
+			SentAt:           time.Now(),
+			ErrorMsg:         reason.Error(),
+		}
+		if err := d.notificationRepo.Create(notificationLog); err != nil {
+			d.logger.WithFields(map[string]interface{}{
+				"channel": recipient.Channel,
+				"error":   err,
+			}).Error("Failed to create notification log")
+		}
+	}
+}
+
+// RecordFiltered 记录被过滤规则拦截的订单通知
+func (d *NotificationDispatcher) RecordFiltered(notificationType model.NotificationType, filtered []FilteredOrder) {
+	for _, item := range filtered {
+		notificationLog := &model.NotificationLog{
+			TraderUserID:     item.Order.TraderUserID,
+			OrderID:          item.Order.OrderID,
+			NotificationType: notificationType,
+			Status:           model.NotificationStatusFiltered,
+			SentAt:           time.Now(),
+			ErrorMsg:         item.Reason,
+		}
+		if err := d.notificationRepo.Create(notificationLog); err != nil {
+			d.logger.WithFields(map[string]interface{}{
+				"order_id": item.Order.OrderID,
+				"error":    err,
+			}).Error("Failed to create notification log")
+		}
+	}
+}
+
+// sendToChannel 在单个渠道渲染一次消息，再逐个发送给接收人
+func (d *NotificationDispatcher) sendToChannel(target dispatchTarget, event NotificationEvent) []ChannelResult {
+	channel := target.channel
+	message, err := safeRender(channel, event)
+	if err != nil {
+		return []ChannelResult{{Channel: channel.Name, Err: err}}
+	}
+	if message == "" {
+		return nil
+	}
+
+	results := make([]ChannelResult, 0, len(target.recipients))
+	for _, recipient := range target.recipients {
+		result := ChannelResult{Channel: channel.Name}
+		if recipient != nil {
+			result.Recipient = recipient.Address
+		}
+		result.Err = d.sendToRecipient(channel, event, message, recipient)
+		results = append(results, result)
+	}
+	return results
+}
+
+// safeRender 生成渠道的消息内容，渠道实现的异常不能影响其他渠道
+func safeRender(channel notification.Channel, event NotificationEvent) (message string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("notification channel %s panicked: %v", channel.Name, r)
+		}
+	}()
+	return event.Render(channel.Client), nil
+}
+
+// sendToRecipient 记录通知日志并发送给单个接收人，recipient 为 nil 时发送到渠道默认接收人
+func (d *NotificationDispatcher) sendToRecipient(channel notification.Channel, event NotificationEvent, message string, recipient *Recipient) error {
+	var subscriberID *uint
+	address := ""
+	if recipient != nil {
+		subscriberID = &recipient.SubscriberID
+		address = recipient.Address
+	}
+
+	batchID := newBatchID()
+	nextRetryAt := time.Now().Add(pendingRetryDelay)
+	notificationIds := make([]uint, 0, len(event.OrderIDs))
+	for _, orderID := range event.OrderIDs {
+		// 记录通知日志
+		notificationLog := &model.NotificationLog{
+			TraderUserID:     event.TraderUserID,
+			OrderID:          orderID,
+			Channel:          channel.Name,
+			NotificationType: event.Type,
+			SubscriberID:     subscriberID,
+			Recipient:        address,
+			BatchID:          batchID,
+			Message:          message,
+			Status:           model.NotificationStatusPending,
+			NextRetryAt:      &nextRetryAt,
+			SentAt:           time.Now(),
+		}
+
+		if err := d.notificationRepo.Create(notificationLog); err != nil {
+			d.logger.WithFields(map[string]interface{}{
+				"channel": channel.Name,
+				"error":   err,
+			}).Error("Failed to create notification log")
+			continue
+		}
+
+		notificationIds = append(notificationIds, notificationLog.ID)
+	}
+
+	sendErr := d.deliver(channel, notificationIds, notification.NotificationMessage{
+		Type:         string(event.Type),
+		Message:      message,
+		Data:         event.Data,
+		To:           address,
+		TraderUserID: event.TraderUserID,
+	}, 0)
+	if sendErr != nil {
+		fields := map[string]interface{}{
+			"channel":   channel.Name,
+			"type":      event.Type,
+			"trader_id": event.TraderUserID,
+			"error":     sendErr,
+		}
+		if recipient != nil {
+			fields["subscriber_id"] = recipient.SubscriberID
+		}
+		d.logger.WithFields(fields).Error("Failed to send notification")
+	}
+
+	return sendErr
+}
+
+// deliver 发送消息并记录结果，previousAttempts 为此前已发送的次数
+// 失败时按退避策略安排下次重试，达到最大次数后标记为 DEAD
+func (d *NotificationDispatcher) deliver(channel notification.Channel, notificationIds []uint, message notification.NotificationMessage, previousAttempts int) error {
+	sendErr := safeSend(channel, message)
+
+	if len(notificationIds) == 0 {
+		return sendErr
+	}
+
+	attempts := previousAttempts + 1
+	status, errorMsg := model.NotificationStatusSuccess, ""
+	var nextRetryAt *time.Time
+	var delivered model.DeliveredParts
+	if sendErr != nil {
+		errorMsg = sendErr.Error()
+		// 记录已送达的接收地址和分段，重试时只发送失败的部分
+		delivered = append(delivered, message.Delivered...)
+		var partial *notification.DeliveryError
+		if errors.As(sendErr, &partial) {
+			delivered = append(delivered, partial.Delivered...)
+		}
+		if attempts >= d.retry.MaxAttempts {
+			status = model.NotificationStatusDead
+		} else {
+			status = model.NotificationStatusFailed
+			next := time.Now().Add(d.retryDelay(attempts))
+			nextRetryAt = &next
+		}
+	}
+
+	if err := d.notificationRepo.RecordAttempt(notificationIds, status, attempts, nextRetryAt, errorMsg, delivered); err != nil {
+		d.logger.WithFields(map[string]interface{}{
+			"channel": channel.Name,
+			"error":   err,
+		}).Error("Failed to update notification status")
+	}
+
+	return sendErr
+}
+
+// safeSend 调用渠道发送消息，渠道实现的异常转换为错误
+func safeSend(channel notification.Channel, message notification.NotificationMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("notification channel %s panicked: %v", channel.Name, r)
+		}
+	}()
+	return channel.Client.SendMessage(message)
+}
+
+// retryDelay 第 attempts 次发送失败后的等待时间
+func (d *NotificationDispatcher) retryDelay(attempts int) time.Duration {
+	delay := d.retry.BaseDelay
+	for i := 1; i < attempts && delay < d.retry.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > d.retry.MaxDelay {
+		delay = d.retry.MaxDelay
+	}
+	return delay
+}
+
+// newBatchID 生成通知批次ID
+func newBatchID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%032x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+// joinChannelErrors 合并各渠道的错误，全部成功时返回 nil
+func joinChannelErrors(results []ChannelResult) error {
+	var errs []error
+	for _, result := range results {
+		if result.Err == nil {
+			continue
+		}
+		if result.Recipient != "" {
+			errs = append(errs, fmt.Errorf("%s (%s): %w", result.Channel, result.Recipient, result.Err))
+		} else {
+			errs = append(errs, fmt.Errorf("%s: %w", result.Channel, result.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"weex-watchdog/internal/model"
+	"weex-watchdog/pkg/notification"
+)
+
+// ErrNotificationNotResendable 通知日志没有保存消息内容（升级前的旧记录），无法重发
+var ErrNotificationNotResendable = errors.New("notification has no stored message")
+
+// StartRetryWorker 定期重发到达重试时间的通知，ctx 取消后退出
+func (d *NotificationDispatcher) StartRetryWorker(ctx context.Context) {
+	d.logger.WithFields(map[string]interface{}{
+		"interval":     d.retry.Interval.String(),
+		"max_attempts": d.retry.MaxAttempts,
+	}).Info("Notification retry worker started")
+
+	ticker := time.NewTicker(d.retry.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.logger.Info("Notification retry worker stopped")
+			return
+		case <-ticker.C:
+			d.retryDue()
+		}
+	}
+}
+
+// retryDue 重发所有到期的通知，同一批次只发送一次
+func (d *NotificationDispatcher) retryDue() {
+	d.retryMu.Lock()
+	defer d.retryMu.Unlock()
+
+	logs, err := d.notificationRepo.GetDueRetries(time.Now(), d.retry.BatchSize)
+	if err != nil {
+		d.logger.WithField("error", err).Error("Failed to load notifications for retry")
+		return
+	}
+
+	handled := make(map[string]bool)
+	for _, log := range logs {
+		key := log.BatchID
+		if key == "" {
+			key = fmt.Sprintf("log-%d", log.ID)
+		}
+		if handled[key] {
+			continue
+		}
+		handled[key] = true
+
+		batch := []model.NotificationLog{log}
+		if log.BatchID != "" {
+			if batch, err = d.notificationRepo.GetByBatchID(log.BatchID); err != nil {
+				d.logger.WithFields(map[string]interface{}{
+					"batch_id": log.BatchID,
+					"error":    err,
+				}).Error("Failed to load notification batch")
+				continue
+			}
+		}
+
+		// 同一批次中只重发仍在等待的记录
+		retryable := batch[:0]
+		for _, item := range batch {
+			if item.Status == model.NotificationStatusPending || item.Status == model.NotificationStatusFailed {
+				retryable = append(retryable, item)
+			}
+		}
+		if len(retryable) == 0 {
+			continue
+		}
+
+		if err := d.resendBatch(retryable, log.Attempts); err != nil {
+			d.logger.WithFields(map[string]interface{}{
+				"notification_id": log.ID,
+				"channel":         log.Channel,
+				"attempts":        log.Attempts + 1,
+				"error":           err,
+			}).Warn("Notification retry failed")
+			continue
+		}
+
+		d.logger.WithFields(map[string]interface{}{
+			"notification_id": log.ID,
+			"channel":         log.Channel,
+			"attempts":        log.Attempts + 1,
+		}).Info("Notification retry succeeded")
+	}
+}
+
+// Resend 手动重发通知日志所在的整批通知，重发后重新计算重试次数
+func (d *NotificationDispatcher) Resend(id uint) (*model.NotificationLog, error) {
+	d.retryMu.Lock()
+	defer d.retryMu.Unlock()
+
+	log, err := d.notificationRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if log.Message == "" {
+		return nil, ErrNotificationNotResendable
+	}
+
+	batch := []model.NotificationLog{*log}
+	if log.BatchID != "" {
+		if batch, err = d.notificationRepo.GetByBatchID(log.BatchID); err != nil {
+			return nil, err
+		}
+	}
+
+	sendErr := d.resendBatch(batch, 0)
+
+	d.logger.WithFields(map[string]interface{}{
+		"notification_id": id,
+		"channel":         log.Channel,
+		"success":         sendErr == nil,
+	}).Info("Notification resent manually")
+
+	updated, err := d.notificationRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return updated, sendErr
+}
+
+// resendBatch 使用保存的消息内容重发一批通知，跳过此前已送达的接收地址和分段，渠道已停用时直接标记为 DEAD
+func (d *NotificationDispatcher) resendBatch(logs []model.NotificationLog, previousAttempts int) error {
+	first := logs[0]
+	ids := make([]uint, 0, len(logs))
+	for _, log := range logs {
+		ids = append(ids, log.ID)
+	}
+
+	channel, ok := d.Channel(first.Channel)
+	if !ok {
+		err := fmt.Errorf("notification channel %q is not enabled", first.Channel)
+		if updateErr := d.notificationRepo.RecordAttempt(ids, model.NotificationStatusDead, first.Attempts, nil, err.Error(), first.Delivered); updateErr != nil {
+			d.logger.WithFields(map[string]interface{}{
+				"channel": first.Channel,
+				"error":   updateErr,
+			}).Error("Failed to update notification status")
+		}
+		return err
+	}
+
+	return d.deliver(channel, ids, notification.NotificationMessage{
+		Type:         string(first.NotificationType),
+		Message:      first.Message,
+		To:           first.Recipient,
+		TraderUserID: first.TraderUserID,
+		Delivered:    first.Delivered,
+	}, previousAttempts)
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"weex-watchdog/internal/model"
+	"weex-watchdog/internal/repository"
+	"weex-watchdog/pkg/logger"
+)
+
+// ErrInvalidRule 过滤规则参数不合法
+var ErrInvalidRule = errors.New("invalid notification rule")
+
+// FilteredOrder 被过滤规则拦截的订单
+type FilteredOrder struct {
+	Order  *model.OrderHistory
+	Reason string
+}
+
+// NotificationRuleService 通知过滤规则服务
+// 对交易员生效的规则包括全局规则和该交易员的规则：匹配任一 EXCLUDE 规则的订单不通知；
+// 存在 INCLUDE 规则时，只通知至少匹配一条 INCLUDE 规则的订单
+type NotificationRuleService struct {
+	ruleRepo repository.NotificationRuleRepository
+	logger   *logger.Logger
+}
+
+// NewNotificationRuleService 创建通知过滤规则服务
+func NewNotificationRuleService(ruleRepo repository.NotificationRuleRepository, logger *logger.Logger) *NotificationRuleService {
+	return &NotificationRuleService{
+		ruleRepo: ruleRepo,
+		logger:   logger,
+	}
+}
+
+// CreateRule 创建规则
+func (s *NotificationRuleService) CreateRule(rule *model.NotificationRule) error {
+	if err := normalizeRule(rule); err != nil {
+		return err
+	}
+	return s.ruleRepo.Create(rule)
+}
+
+// GetRules 获取规则列表
+func (s *NotificationRuleService) GetRules(traderUserID string) ([]model.NotificationRule, error) {
+	return s.ruleRepo.GetAll(traderUserID)
+}
+
+// GetRuleByID 根据ID获取规则
+func (s *NotificationRuleService) GetRuleByID(id uint) (*model.NotificationRule, error) {
+	return s.ruleRepo.GetByID(id)
+}
+
+// UpdateRule 更新规则
+func (s *NotificationRuleService) UpdateRule(rule *model.NotificationRule) error {
+	if err := normalizeRule(rule); err != nil {
+		return err
+	}
+	return s.ruleRepo.Update(rule)
+}
+
+// DeleteRule 删除规则
+func (s *NotificationRuleService) DeleteRule(id uint) error {
+	return s.ruleRepo.Delete(id)
+}
+
+// Filter 按规则拆分需要通知和被拦截的订单，规则加载失败时全部通知
+func (s *NotificationRuleService) Filter(traderUserID string, orders []*model.OrderHistory, now time.Time) (passed []*model.OrderHistory, filtered []FilteredOrder) {
+	rules, err := s.ruleRepo.GetActiveRules(traderUserID)
+	if err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"trader_id": traderUserID,
+			"error":     err,
+		}).Error("Failed to load notification rules")
+		return orders, nil
+	}
+	if len(rules) == 0 {
+		return orders, nil
+	}
+
+	for _, order := range orders {
+		if reason, ok := evaluateRules(rules, order, now); ok {
+			passed = append(passed, order)
+		} else {
+			filtered = append(filtered, FilteredOrder{Order: order, Reason: reason})
+		}
+	}
+	return passed, filtered
+}
+
+// evaluateRules 判断订单是否需要通知，不通知时返回原因
+func evaluateRules(rules []model.NotificationRule, order *model.OrderHistory, now time.Time) (string, bool) {
+	hasInclude, included := false, false
+	for i := range rules {
+		rule := &rules[i]
+		matched := ruleMatches(rule, order, now)
+		switch rule.Action {
+		case model.RuleActionExclude:
+			if matched {
+				return fmt.Sprintf("excluded by rule #%d %s", rule.ID, rule.Name), false
+			}
+		case model.RuleActionInclude:
+			hasInclude = true
+			included = included || matched
+		}
+	}
+	if hasInclude && !included {
+		return "no include rule matched", false
+	}
+	return "", true
+}
+
+// ruleMatches 订单是否满足规则的所有条件
+func ruleMatches(rule *model.NotificationRule, order *model.OrderHistory, now time.Time) bool {
+	if rule.ContractSymbol != "" && !symbolMatches(rule.ContractSymbol, order.ContractSymbol) {
+		return false
+	}
+	if rule.PositionSide != "" && rule.PositionSide != order.PositionSide {
+		return false
+	}
+	if rule.MinLeverage > 0 {
+		leverage, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(order.OpenLeverage), "x"), 64)
+		if err != nil || leverage < rule.MinLeverage {
+			return false
+		}
+	}
+	if rule.MinNotional > 0 {
+		size, sizeErr := strconv.ParseFloat(order.OpenSize, 64)
+		price, priceErr := strconv.ParseFloat(order.OpenPrice, 64)
+		if sizeErr != nil || priceErr != nil || size*price < rule.MinNotional {
+			return false
+		}
+	}
+	if rule.QuietStart != "" && !inTimeWindow(rule.QuietStart, rule.QuietEnd, now) {
+		return false
+	}
+	return true
+}
+
+// symbolMatches 币种是否匹配，规则可以写完整交易对（BTC/USDT）或只写币种（BTC）
+func symbolMatches(ruleSymbol, orderSymbol string) bool {
+	if strings.EqualFold(ruleSymbol, orderSymbol) {
+		return true
+	}
+	base, _, _ := strings.Cut(orderSymbol, "/")
+	return strings.EqualFold(ruleSymbol, base)
+}
+
+// inTimeWindow 当前时间是否在 [start, end) 时段内，end 早于 start 时表示跨零点
+func inTimeWindow(start, end string, now time.Time) bool {
+	startMinute, err1 := parseClock(start)
+	endMinute, err2 := parseClock(end)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	minute := now.Hour()*60 + now.Minute()
+	if startMinute <= endMinute {
+		return minute >= startMinute && minute < endMinute
+	}
+	return minute >= startMinute || minute < endMinute
+}
+
+// parseClock 解析 HH:MM，返回当天的分钟数
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// normalizeRule 校验规则并规范取值
+func normalizeRule(rule *model.NotificationRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	rule.TraderUserID = strings.TrimSpace(rule.TraderUserID)
+	rule.Action = model.RuleAction(strings.ToUpper(string(rule.Action)))
+	rule.ContractSymbol = strings.ToUpper(strings.TrimSpace(rule.ContractSymbol))
+	rule.PositionSide = strings.ToUpper(strings.TrimSpace(rule.PositionSide))
+
+	if rule.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRule)
+	}
+	if rule.Action != model.RuleActionInclude && rule.Action != model.RuleActionExclude {
+		return fmt.Errorf("%w: action must be INCLUDE or EXCLUDE", ErrInvalidRule)
+	}
+	if rule.PositionSide != "" && rule.PositionSide != "LONG" && rule.PositionSide != "SHORT" {
+		return fmt.Errorf("%w: position_side must be LONG or SHORT", ErrInvalidRule)
+	}
+	if rule.MinLeverage < 0 || rule.MinNotional < 0 {
+		return fmt.Errorf("%w: min_leverage and min_notional cannot be negative", ErrInvalidRule)
+	}
+	if (rule.QuietStart == "") != (rule.QuietEnd == "") {
+		return fmt.Errorf("%w: quiet_start and quiet_end must be set together", ErrInvalidRule)
+	}
+	if rule.QuietStart != "" {
+		if _, err := parseClock(rule.QuietStart); err != nil {
+			return fmt.Errorf("%w: quiet_start must be HH:MM", ErrInvalidRule)
+		}
+		if _, err := parseClock(rule.QuietEnd); err != nil {
+			return fmt.Errorf("%w: quiet_end must be HH:MM", ErrInvalidRule)
+		}
+	}
+	if rule.ContractSymbol == "" && rule.PositionSide == "" && rule.MinLeverage == 0 && rule.MinNotional == 0 && rule.QuietStart == "" {
+		return fmt.Errorf("%w: at least one condition is required", ErrInvalidRule)
+	}
+	return nil
+}
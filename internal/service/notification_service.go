@@ -1,6 +1,8 @@
 package service
 
 import (
+	"fmt"
+
 	"weex-watchdog/internal/model"
 	"weex-watchdog/internal/repository"
 	"weex-watchdog/pkg/logger"
@@ -10,34 +12,93 @@ import (
 // NotificationService 通知服务
 type NotificationService struct {
 	notificationRepo repository.NotificationRepository
-	notificationClient notification.Client
+	dispatcher       *NotificationDispatcher
+	templateRenderer *notification.TemplateRenderer
 	logger           *logger.Logger
 }
 
 // NewNotificationService 创建通知服务
-func NewNotificationService(notificationRepo repository.NotificationRepository, notificationClient notification.Client, logger *logger.Logger) *NotificationService {
+func NewNotificationService(notificationRepo repository.NotificationRepository, dispatcher *NotificationDispatcher, templateRenderer *notification.TemplateRenderer, logger *logger.Logger) *NotificationService {
 	return &NotificationService{
 		notificationRepo: notificationRepo,
-		notificationClient: notificationClient,
+		dispatcher:       dispatcher,
+		templateRenderer: templateRenderer,
 		logger:           logger,
 	}
 }
 
 // GetNotificationLogs 获取通知记录
-func (s *NotificationService) GetNotificationLogs(traderUserID string, page, pageSize int) ([]model.NotificationLog, int64, error) {
+func (s *NotificationService) GetNotificationLogs(traderUserID string, filters map[string]interface{}, page, pageSize int) ([]model.NotificationLog, int64, error) {
 	offset := (page - 1) * pageSize
-	return s.notificationRepo.GetLogs(traderUserID, offset, pageSize)
+	return s.notificationRepo.GetLogs(traderUserID, filters, offset, pageSize)
+}
+
+// ResendNotification 手动重发通知，返回重发后的通知记录
+func (s *NotificationService) ResendNotification(id uint) (*model.NotificationLog, error) {
+	return s.dispatcher.Resend(id)
 }
 
-// TestNotification 发送测试消息
+// TestNotification 向所有渠道发送测试消息
 func (s *NotificationService) TestNotification(message string) error {
-	notificationMsg := &notification.NotificationMessage{
-		Type: string(model.NotificationTypeNewOrder),
-		Message: message,
-	}
-	if err := s.notificationClient.SendMessage(*notificationMsg); err != nil {
+	results := s.dispatcher.Dispatch(NotificationEvent{
+		Type: model.NotificationTypeNewOrder,
+		Render: func(client notification.Client) string {
+			return notification.FormatPlainText(client, message)
+		},
+	})
+	if err := joinChannelErrors(results); err != nil {
 		s.logger.WithField("error", err).Error("Failed to send test notification")
 		return err
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// TemplatePreview 模板预览结果
+type TemplatePreview struct {
+	Channel string `json:"channel"`
+	Event   string `json:"event"`
+	Source  string `json:"source"` // inline 请求中的模板 / file 模板文件 / builtin 渠道内置格式
+	Content string `json:"content"`
+}
+
+// PreviewTemplate 使用示例订单渲染模板，templateText 为空时渲染渠道当前生效的模板文件或内置格式
+func (s *NotificationService) PreviewTemplate(channelName string, event model.NotificationType, templateText string, isHTML bool) (*TemplatePreview, error) {
+	data, err := notification.SampleTemplateData(event)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &TemplatePreview{Channel: channelName, Event: string(event)}
+
+	if templateText != "" {
+		content, err := notification.RenderTemplate(templateText, isHTML, data)
+		if err != nil {
+			return nil, err
+		}
+		preview.Source, preview.Content = "inline", content
+		return preview, nil
+	}
+
+	channel, ok := s.dispatcher.Channel(channelName)
+	if !ok {
+		return nil, fmt.Errorf("notification channel %s is not enabled", channelName)
+	}
+
+	content, found, err := s.templateRenderer.Render(channelName, event, data)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		preview.Source, preview.Content = "file", content
+		return preview, nil
+	}
+
+	client := notification.Unwrap(channel.Client)
+	if event == model.NotificationTypeOrderUpdated {
+		preview.Content = client.BuildOrderChangeMessage(data.Changes)
+	} else {
+		preview.Content = client.BuildNotificationMessage(data.Orders, data.IsOpen)
+	}
+	preview.Source = "builtin"
+	return preview, nil
+}
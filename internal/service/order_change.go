@@ -0,0 +1,131 @@
+package service
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+
+	"weex-watchdog/internal/model"
+	"weex-watchdog/pkg/weex"
+)
+
+// priceEpsilon 数值比较的容差，避免网关返回的精度差异被当成变化
+const priceEpsilon = 1e-9
+
+// diffOrder 对比订单快照与当前订单，生成持仓变化事件
+func diffOrder(stored *model.OrderHistory, previous, current weex.OpenOrder, now time.Time) []*model.OrderChangeEvent {
+	var events []*model.OrderChangeEvent
+	add := func(changeType model.OrderChangeType, oldValue, newValue string) {
+		events = append(events, &model.OrderChangeEvent{
+			TraderUserID:   stored.TraderUserID,
+			TraderName:     stored.TraderName,
+			OrderID:        stored.OrderID,
+			ContractSymbol: stored.ContractSymbol,
+			PositionSide:   stored.PositionSide,
+			ChangeType:     changeType,
+			OldValue:       oldValue,
+			NewValue:       newValue,
+			DetectedAt:     now,
+		})
+	}
+
+	// 止盈止损
+	if changeType, ok := diffTrigger(previous.TakeProfitPrice, current.TakeProfitPrice,
+		model.OrderChangeTakeProfitAdded, model.OrderChangeTakeProfitMoved, model.OrderChangeTakeProfitRemoved); ok {
+		add(changeType, previous.TakeProfitPrice, current.TakeProfitPrice)
+	}
+	if changeType, ok := diffTrigger(previous.StopLossPrice, current.StopLossPrice,
+		model.OrderChangeStopLossAdded, model.OrderChangeStopLossMoved, model.OrderChangeStopLossRemoved); ok {
+		add(changeType, previous.StopLossPrice, current.StopLossPrice)
+	}
+
+	// 仓位大小：成交数量增加为加仓，平仓数量增加为部分平仓
+	sizeChanged := false
+	oldFill, newFill := filledSize(previous), filledSize(current)
+	if newFill > oldFill+priceEpsilon {
+		add(model.OrderChangeScaledIn, formatSize(previous), formatSize(current))
+		sizeChanged = true
+	}
+	if parseDecimal(current.CloseFillSize) > parseDecimal(previous.CloseFillSize)+priceEpsilon {
+		add(model.OrderChangePartialClose, previous.CloseFillSize, current.CloseFillSize)
+		sizeChanged = true
+	}
+
+	// 保证金
+	marginChanged := false
+	if !decimalEqual(previous.OpenMarginAmount, current.OpenMarginAmount) {
+		add(model.OrderChangeMarginChanged, previous.OpenMarginAmount, current.OpenMarginAmount)
+		marginChanged = true
+	}
+
+	// 强平价：仓位或保证金变化引起的强平价变动已包含在上面的事件中，不单独通知
+	if !sizeChanged && !marginChanged && !decimalEqual(previous.LiquidatePrice, current.LiquidatePrice) {
+		add(model.OrderChangeLiquidationMoved, previous.LiquidatePrice, current.LiquidatePrice)
+	}
+
+	return events
+}
+
+// diffTrigger 对比止盈/止损价格，未设置的价格为空或0
+func diffTrigger(previous, current string, added, moved, removed model.OrderChangeType) (model.OrderChangeType, bool) {
+	oldPrice, newPrice := parseDecimal(previous), parseDecimal(current)
+	switch {
+	case oldPrice == 0 && newPrice == 0:
+		return "", false
+	case oldPrice == 0:
+		return added, true
+	case newPrice == 0:
+		return removed, true
+	case math.Abs(oldPrice-newPrice) > priceEpsilon:
+		return moved, true
+	default:
+		return "", false
+	}
+}
+
+// snapshotToOrder 将订单快照还原为网关订单结构
+func snapshotToOrder(data model.JSON) (weex.OpenOrder, bool) {
+	var order weex.OpenOrder
+	if len(data) == 0 {
+		return order, false
+	}
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return order, false
+	}
+	if err := json.Unmarshal(bytes, &order); err != nil {
+		return order, false
+	}
+	return order, true
+}
+
+// filledSize 已成交的开仓数量，网关未返回成交数量时使用委托数量
+func filledSize(order weex.OpenOrder) float64 {
+	if size := parseDecimal(order.OpenFillSize); size > 0 {
+		return size
+	}
+	return parseDecimal(order.OpenSize)
+}
+
+// formatSize 用于展示的开仓数量
+func formatSize(order weex.OpenOrder) string {
+	if parseDecimal(order.OpenFillSize) > 0 {
+		return order.OpenFillSize
+	}
+	return order.OpenSize
+}
+
+// parseDecimal 解析数值字符串，无法解析时返回0
+func parseDecimal(value string) float64 {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
+// decimalEqual 比较两个数值字符串
+func decimalEqual(a, b string) bool {
+	return math.Abs(parseDecimal(a)-parseDecimal(b)) <= priceEpsilon
+}
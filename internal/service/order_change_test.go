@@ -0,0 +1,178 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"weex-watchdog/internal/model"
+	"weex-watchdog/pkg/weex"
+)
+
+func TestDiffOrder(t *testing.T) {
+	base := weex.OpenOrder{
+		OpenSize:         "0.5",
+		OpenFillSize:     "0.5",
+		CloseFillSize:    "0",
+		OpenMarginAmount: "1000",
+		LiquidatePrice:   "52000",
+		TakeProfitPrice:  "70000",
+		StopLossPrice:    "60000",
+	}
+	with := func(change func(order *weex.OpenOrder)) weex.OpenOrder {
+		order := base
+		change(&order)
+		return order
+	}
+
+	type event struct {
+		changeType model.OrderChangeType
+		oldValue   string
+		newValue   string
+	}
+	tests := []struct {
+		name     string
+		previous weex.OpenOrder
+		current  weex.OpenOrder
+		want     []event
+	}{
+		{
+			name:     "no change",
+			previous: base,
+			current:  base,
+		},
+		{
+			name:     "same values in different precision",
+			previous: base,
+			current: with(func(o *weex.OpenOrder) {
+				o.TakeProfitPrice = "70000.00"
+				o.OpenMarginAmount = "1000.0"
+				o.LiquidatePrice = "52000.000000"
+			}),
+		},
+		{
+			name:     "take profit added",
+			previous: with(func(o *weex.OpenOrder) { o.TakeProfitPrice = "" }),
+			current:  base,
+			want:     []event{{model.OrderChangeTakeProfitAdded, "", "70000"}},
+		},
+		{
+			name:     "take profit moved",
+			previous: base,
+			current:  with(func(o *weex.OpenOrder) { o.TakeProfitPrice = "72000" }),
+			want:     []event{{model.OrderChangeTakeProfitMoved, "70000", "72000"}},
+		},
+		{
+			name:     "take profit removed",
+			previous: base,
+			current:  with(func(o *weex.OpenOrder) { o.TakeProfitPrice = "0" }),
+			want:     []event{{model.OrderChangeTakeProfitRemoved, "70000", "0"}},
+		},
+		{
+			name:     "stop loss added",
+			previous: with(func(o *weex.OpenOrder) { o.StopLossPrice = "0" }),
+			current:  base,
+			want:     []event{{model.OrderChangeStopLossAdded, "0", "60000"}},
+		},
+		{
+			name:     "stop loss moved",
+			previous: base,
+			current:  with(func(o *weex.OpenOrder) { o.StopLossPrice = "61500.5" }),
+			want:     []event{{model.OrderChangeStopLossMoved, "60000", "61500.5"}},
+		},
+		{
+			name:     "stop loss removed",
+			previous: base,
+			current:  with(func(o *weex.OpenOrder) { o.StopLossPrice = "" }),
+			want:     []event{{model.OrderChangeStopLossRemoved, "60000", ""}},
+		},
+		{
+			name:     "scaled in",
+			previous: base,
+			current: with(func(o *weex.OpenOrder) {
+				o.OpenSize = "0.8"
+				o.OpenFillSize = "0.8"
+				o.LiquidatePrice = "54000"
+			}),
+			want: []event{{model.OrderChangeScaledIn, "0.5", "0.8"}},
+		},
+		{
+			name:     "scaled in without fill size",
+			previous: with(func(o *weex.OpenOrder) { o.OpenFillSize = "" }),
+			current: with(func(o *weex.OpenOrder) {
+				o.OpenSize = "0.7"
+				o.OpenFillSize = ""
+			}),
+			want: []event{{model.OrderChangeScaledIn, "0.5", "0.7"}},
+		},
+		{
+			name:     "partial close",
+			previous: base,
+			current: with(func(o *weex.OpenOrder) {
+				o.CloseFillSize = "0.2"
+				o.LiquidatePrice = "50000"
+			}),
+			want: []event{{model.OrderChangePartialClose, "0", "0.2"}},
+		},
+		{
+			name:     "margin changed",
+			previous: base,
+			current: with(func(o *weex.OpenOrder) {
+				o.OpenMarginAmount = "1500"
+				o.LiquidatePrice = "48000"
+			}),
+			want: []event{{model.OrderChangeMarginChanged, "1000", "1500"}},
+		},
+		{
+			name:     "liquidation price moved",
+			previous: base,
+			current:  with(func(o *weex.OpenOrder) { o.LiquidatePrice = "51000" }),
+			want:     []event{{model.OrderChangeLiquidationMoved, "52000", "51000"}},
+		},
+		{
+			name:     "several changes",
+			previous: base,
+			current: with(func(o *weex.OpenOrder) {
+				o.StopLossPrice = "65000"
+				o.CloseFillSize = "0.1"
+				o.OpenMarginAmount = "900"
+			}),
+			want: []event{
+				{model.OrderChangeStopLossMoved, "60000", "65000"},
+				{model.OrderChangePartialClose, "0", "0.1"},
+				{model.OrderChangeMarginChanged, "1000", "900"},
+			},
+		},
+	}
+
+	stored := &model.OrderHistory{
+		TraderUserID:   "4200001",
+		TraderName:     "trader",
+		OrderID:        "order-1",
+		ContractSymbol: "BTCUSDT",
+		PositionSide:   "LONG",
+	}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := diffOrder(stored, tt.previous, tt.current, now)
+			if len(events) != len(tt.want) {
+				var got []model.OrderChangeType
+				for _, e := range events {
+					got = append(got, e.ChangeType)
+				}
+				t.Fatalf("got events %v, want %d events", got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				e := events[i]
+				if e.ChangeType != want.changeType || e.OldValue != want.oldValue || e.NewValue != want.newValue {
+					t.Errorf("event %d = %s %q -> %q, want %s %q -> %q",
+						i, e.ChangeType, e.OldValue, e.NewValue, want.changeType, want.oldValue, want.newValue)
+				}
+				if e.OrderID != stored.OrderID || e.TraderUserID != stored.TraderUserID || !e.DetectedAt.Equal(now) {
+					t.Errorf("event %d not attributed to the stored order: %+v", i, e)
+				}
+			}
+		})
+	}
+}
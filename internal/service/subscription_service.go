@@ -0,0 +1,129 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"weex-watchdog/internal/model"
+	"weex-watchdog/internal/repository"
+	"weex-watchdog/pkg/logger"
+)
+
+// ErrInvalidSubscriber 订阅人参数不合法
+var ErrInvalidSubscriber = errors.New("invalid subscriber")
+
+// SubscriptionService 通知订阅服务
+type SubscriptionService struct {
+	subscriberRepo repository.SubscriberRepository
+	dispatcher     *NotificationDispatcher
+	logger         *logger.Logger
+}
+
+// NewSubscriptionService 创建通知订阅服务
+func NewSubscriptionService(subscriberRepo repository.SubscriberRepository, dispatcher *NotificationDispatcher, logger *logger.Logger) *SubscriptionService {
+	return &SubscriptionService{
+		subscriberRepo: subscriberRepo,
+		dispatcher:     dispatcher,
+		logger:         logger,
+	}
+}
+
+// CreateSubscriber 创建订阅人及其订阅
+func (s *SubscriptionService) CreateSubscriber(subscriber *model.Subscriber) error {
+	if err := s.normalize(subscriber); err != nil {
+		return err
+	}
+	return s.subscriberRepo.Create(subscriber)
+}
+
+// GetSubscribers 获取订阅人列表
+func (s *SubscriptionService) GetSubscribers(page, pageSize int) ([]model.Subscriber, int64, error) {
+	offset := (page - 1) * pageSize
+	return s.subscriberRepo.GetAll(offset, pageSize)
+}
+
+// GetSubscriberByID 根据ID获取订阅人
+func (s *SubscriptionService) GetSubscriberByID(id uint) (*model.Subscriber, error) {
+	return s.subscriberRepo.GetByID(id)
+}
+
+// UpdateSubscriber 更新订阅人，订阅列表整体替换
+func (s *SubscriptionService) UpdateSubscriber(subscriber *model.Subscriber) error {
+	if err := s.normalize(subscriber); err != nil {
+		return err
+	}
+	return s.subscriberRepo.Update(subscriber)
+}
+
+// DeleteSubscriber 删除订阅人及其订阅
+func (s *SubscriptionService) DeleteSubscriber(id uint) error {
+	return s.subscriberRepo.Delete(id)
+}
+
+// Recipients 交易员事件的接收人
+// subscribed 为 false 表示该交易员没有任何订阅，事件发送到各渠道配置的默认接收人
+func (s *SubscriptionService) Recipients(traderUserID string, notificationType model.NotificationType) (recipients []Recipient, subscribed bool, err error) {
+	subscribers, err := s.subscriberRepo.GetByTrader(traderUserID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, subscriber := range subscribers {
+		if !subscriber.IsActive {
+			continue
+		}
+		for _, subscription := range subscriber.Subscriptions {
+			if subscription.EventTypes.Contains(notificationType) {
+				recipients = append(recipients, Recipient{
+					SubscriberID: subscriber.ID,
+					Channel:      subscriber.Channel,
+					Address:      subscriber.Address,
+				})
+				break
+			}
+		}
+	}
+
+	return recipients, len(subscribers) > 0, nil
+}
+
+// normalize 校验订阅人并规范渠道名称和事件类型
+func (s *SubscriptionService) normalize(subscriber *model.Subscriber) error {
+	subscriber.Name = strings.TrimSpace(subscriber.Name)
+	subscriber.Channel = strings.ToLower(strings.TrimSpace(subscriber.Channel))
+	subscriber.Address = strings.TrimSpace(subscriber.Address)
+
+	if subscriber.Name == "" || subscriber.Address == "" {
+		return fmt.Errorf("%w: subscriber name and address are required", ErrInvalidSubscriber)
+	}
+	if _, ok := s.dispatcher.Channel(subscriber.Channel); !ok {
+		return fmt.Errorf("%w: notification channel %q is not enabled, available channels: %s", ErrInvalidSubscriber,
+			subscriber.Channel, strings.Join(s.dispatcher.ChannelNames(), ", "))
+	}
+
+	seen := make(map[string]bool)
+	for i := range subscriber.Subscriptions {
+		subscription := &subscriber.Subscriptions[i]
+		subscription.TraderUserID = strings.TrimSpace(subscription.TraderUserID)
+		if subscription.TraderUserID == "" {
+			return fmt.Errorf("%w: subscription trader_user_id is required", ErrInvalidSubscriber)
+		}
+		if seen[subscription.TraderUserID] {
+			return fmt.Errorf("%w: trader %s is subscribed more than once", ErrInvalidSubscriber, subscription.TraderUserID)
+		}
+		seen[subscription.TraderUserID] = true
+
+		for j, eventType := range subscription.EventTypes {
+			eventType = model.NotificationType(strings.ToUpper(string(eventType)))
+			switch eventType {
+			case model.NotificationTypeNewOrder, model.NotificationTypeOrderClosed, model.NotificationTypeOrderUpdated:
+				subscription.EventTypes[j] = eventType
+			default:
+				return fmt.Errorf("%w: unsupported notification event %s", ErrInvalidSubscriber, eventType)
+			}
+		}
+	}
+
+	return nil
+}
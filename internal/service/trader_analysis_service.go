@@ -22,13 +22,15 @@ type RedisClient interface {
 
 // TraderAnalysisService 交易员分析服务
 type TraderAnalysisService struct {
+	weexClient  weex.API
 	redisClient RedisClient
 	logger      *logger.Logger
 }
 
 // NewTraderAnalysisService 创建交易员分析服务
-func NewTraderAnalysisService(redisClient RedisClient, logger *logger.Logger) *TraderAnalysisService {
+func NewTraderAnalysisService(weexClient weex.API, redisClient RedisClient, logger *logger.Logger) *TraderAnalysisService {
 	return &TraderAnalysisService{
+		weexClient:  weexClient,
 		redisClient: redisClient,
 		logger:      logger,
 	}
@@ -122,8 +124,8 @@ func (s *TraderAnalysisService) AnalyzeTrader(traderID string, timeRange string,
 		}
 	}
 
-	// 取历weex上历史订单数据
-	orders, err := weex.GetHistoryOrderList(traderID)
+	// 取历weex上历史订单数据，只翻页到时间范围起点为止
+	orders, err := s.fetchHistoryOrders(traderID, timeRange)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get history orders: %w", err)
 	}
@@ -149,25 +151,33 @@ func (s *TraderAnalysisService) AnalyzeTrader(traderID string, timeRange string,
 	return result, nil
 }
 
-// filterOrdersByTimeRange 根据时间范围过滤订单
-func (s *TraderAnalysisService) filterOrdersByTimeRange(orders []weex.OpenOrder, timeRange string) []weex.OpenOrder {
-	if timeRange == "" || timeRange == "all" {
-		return orders
-	}
+// fetchHistoryOrders 分页拉取时间范围内的历史订单
+func (s *TraderAnalysisService) fetchHistoryOrders(traderID, timeRange string) ([]weex.OpenOrder, error) {
+	query := weex.HistoryQuery{Since: s.timeRangeStart(timeRange)}
+	return weex.ListHistoryOrders(context.Background(), s.weexClient, traderID, query)
+}
 
+// timeRangeStart 时间范围对应的起始时间，不限制时返回零值
+func (s *TraderAnalysisService) timeRangeStart(timeRange string) time.Time {
 	now := time.Now()
-	var startTime time.Time
-
 	switch timeRange {
 	case "7d":
-		startTime = now.AddDate(0, 0, -7)
+		return now.AddDate(0, 0, -7)
 	case "30d":
-		startTime = now.AddDate(0, 0, -30)
+		return now.AddDate(0, 0, -30)
 	case "90d":
-		startTime = now.AddDate(0, 0, -90)
+		return now.AddDate(0, 0, -90)
 	case "1y":
-		startTime = now.AddDate(-1, 0, 0)
+		return now.AddDate(-1, 0, 0)
 	default:
+		return time.Time{}
+	}
+}
+
+// filterOrdersByTimeRange 根据时间范围过滤订单
+func (s *TraderAnalysisService) filterOrdersByTimeRange(orders []weex.OpenOrder, timeRange string) []weex.OpenOrder {
+	startTime := s.timeRangeStart(timeRange)
+	if startTime.IsZero() {
 		return orders
 	}
 
@@ -315,7 +325,7 @@ func (s *TraderAnalysisService) analyzeOrders(traderID string, orders []weex.Ope
 
 // calculateFollowProfit 计算跟投收益（模拟真实跟单，带资金曲线和最大回撤）
 func (s *TraderAnalysisService) calculateFollowProfit(traderID, timeRange string, initialCapital, investPerOrder float64) *FollowProfitResult {
-	orders, err := weex.GetHistoryOrderList(traderID)
+	orders, err := s.fetchHistoryOrders(traderID, timeRange)
 	if err != nil {
 		s.logger.Error("Failed to get history orders for follow profit calculation", "error", err)
 		return nil
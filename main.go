@@ -1,10 +1,17 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
@@ -45,10 +52,13 @@ func main() {
 	}
 	appLogger.Info("Database initialized successfully")
 
+	// 初始化Weex网关客户端
+	weexClient := weex.NewClient(&config.Weex, appLogger)
+
 	// 加载合约映射
 	appLogger.Info("Loading contract mappings...")
 	contractMapper := weex.GetContractMapper()
-	if err := contractMapper.LoadContractMapping(); err != nil {
+	if err := contractMapper.LoadContractMapping(context.Background(), weexClient); err != nil {
 		appLogger.Error("Failed to load contract mappings (will continue with empty mappings):", err)
 	} else {
 		mappingCount := contractMapper.GetMappingCount()
@@ -59,51 +69,110 @@ func main() {
 	traderRepo := repository.NewTraderRepository(db)
 	orderRepo := repository.NewOrderRepository(db)
 	notificationRepo := repository.NewNotificationRepository(db)
+	subscriberRepo := repository.NewSubscriberRepository(db)
+	notificationRuleRepo := repository.NewNotificationRuleRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	auditLogRepo := repository.NewAuditLogRepository(db)
+	loginThrottleRepo := repository.NewLoginThrottleRepository(db)
 
 	// 初始化缓存
 	memoryCache := cache.NewMemoryCache()
 
-	// 初始化通知服务
-	notificationClient, err := notification.CreateClient(&config.Notification)
+	// 初始化通知渠道
+	templateRenderer := notification.NewTemplateRenderer(config.Notification.TemplateDir, appLogger)
+	notificationChannels, err := notification.CreateChannels(&config.Notification, templateRenderer)
 	if err != nil {
-		appLogger.Error("Failed to create notification client:", err)
+		appLogger.Error("Failed to create notification channels:", err)
 		os.Exit(1)
 	}
+	notificationDispatcher := service.NewNotificationDispatcher(notificationRepo, notificationChannels, config.Notification.Retry, appLogger)
+	appLogger.Info("Notification channels enabled:", notificationDispatcher.ChannelNames())
 
 	// 初始化业务服务
 	orderService := service.NewOrderService(orderRepo, appLogger)
 	traderService := service.NewTraderService(traderRepo, orderService, appLogger)
-	traderAnalysisService := service.NewTraderAnalysisService(memoryCache, appLogger)  // 添加交易员分析服务
-	notificationService := service.NewNotificationService(notificationRepo, notificationClient, appLogger)
+	traderAnalysisService := service.NewTraderAnalysisService(weexClient, memoryCache, appLogger)  // 添加交易员分析服务
+	subscriptionService := service.NewSubscriptionService(subscriberRepo, notificationDispatcher, appLogger)
+	notificationRuleService := service.NewNotificationRuleService(notificationRuleRepo, appLogger)
+	notificationService := service.NewNotificationService(notificationRepo, notificationDispatcher, templateRenderer, appLogger)
 	monitorService := service.NewMonitorService(
 		traderRepo,
 		orderRepo,
-		notificationRepo,
-		notificationClient,
+		notificationDispatcher,
+		subscriptionService,
+		notificationRuleService,
+		weexClient,
+		config.Monitor,
 		appLogger,
-		config.Weex.APIURL,
 	)
 	traderService.SetMonitorService(monitorService)
+	authService := service.NewAuthService(userRepo, sessionRepo, loginThrottleRepo, config.Auth, appLogger)
+	if err := authService.EnsureBootstrapAdmin(config.Auth.Username, config.Auth.Password); err != nil {
+		appLogger.Error("Failed to create bootstrap admin:", err)
+		os.Exit(1)
+	}
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo, appLogger)
+	auditService := service.NewAuditService(auditLogRepo, appLogger)
 
 	// 初始化处理器
-	traderHandler := handler.NewTraderHandler(traderService, appLogger)
+	traderHandler := handler.NewTraderHandler(traderService, auditService, appLogger)
 	orderHandler := handler.NewOrderHandler(orderService, appLogger)
-	notificationHandler := handler.NewNotificationHandler(notificationService, appLogger)
+	notificationHandler := handler.NewNotificationHandler(notificationService, auditService, appLogger)
+	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionService, auditService, appLogger)
+	notificationRuleHandler := handler.NewNotificationRuleHandler(notificationRuleService, auditService, appLogger)
 	analysisHandler := handler.NewTraderAnalysisHandler(traderAnalysisService, appLogger)  // 添加分析处理器
-	authHandler := handler.NewAuthHandler(config.Auth.Username, config.Auth.Password, []byte(config.Auth.AESKey), appLogger)
+	monitorHandler := handler.NewMonitorHandler(monitorService, appLogger)
+	authHandler := handler.NewAuthHandler(authService, auditService, appLogger)
+	userHandler := handler.NewUserHandler(authService, auditService, appLogger)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService, auditService, appLogger)
+	auditHandler := handler.NewAuditHandler(auditService, appLogger)
 
 	// 设置Gin模式
 	gin.SetMode(config.Server.Mode)
 
 	// 创建Gin引擎
 	engine := gin.New()
+	// 只信任配置的反向代理，否则客户端可以伪造 X-Forwarded-For 绕过按IP的登录限制
+	if err := engine.SetTrustedProxies(config.Server.TrustedProxies); err != nil {
+		appLogger.Error("Invalid trusted proxies:", err)
+		os.Exit(1)
+	}
 
 	// 设置路由
-	router := api.NewRouter(traderHandler, orderHandler, notificationHandler, analysisHandler, authHandler, []byte(config.Auth.AESKey), config.Auth.Username, config.Auth.Password)
+	router := api.NewRouter(traderHandler, orderHandler, notificationHandler, subscriptionHandler, notificationRuleHandler, analysisHandler, monitorHandler, authHandler, userHandler, apiKeyHandler, auditHandler, authService, apiKeyService)
 	router.SetupRoutes(engine)
 
+	// 监听退出信号
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	// 启动监控服务
-	go monitorService.StartMonitoring()
+	go monitorService.StartMonitoring(ctx)
+
+	// 后台任务，退出时等待正在进行的发送完成后再关闭数据库
+	var workers sync.WaitGroup
+	startWorker := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(ctx)
+		}()
+	}
+
+	// 启动失败通知重试
+	startWorker(notificationDispatcher.StartRetryWorker)
+
+	// 清理过期会话和登录失败计数
+	startWorker(authService.StartSessionCleanup)
+
+	// 启动邮件汇总
+	for _, channel := range notificationChannels {
+		if emailClient, ok := notification.Unwrap(channel.Client).(*notification.EmailNotificationClient); ok && emailClient.DigestEnabled() {
+			startWorker(service.NewEmailDigestService(orderRepo, emailClient, appLogger).Start)
+		}
+	}
 
 	// 启动HTTP服务器
 	port := config.Server.Port
@@ -111,10 +180,62 @@ func main() {
 		port = "8080"
 	}
 
-	appLogger.Info("Server starting on port:", port)
-	if err := engine.Run(":" + port); err != nil {
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: engine,
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		appLogger.Info("Server starting on port:", port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		appLogger.Info("Shutdown signal received, draining...")
+	case err := <-serverErr:
 		appLogger.Error("Failed to start server:", err)
-		os.Exit(1)
+		exitCode = 1
+		stop()
+	}
+
+	// 优雅关闭：停止接收请求，等待正在进行的拉取和通知完成
+	shutdownTimeout := config.Server.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = 30 * time.Second
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		appLogger.Error("HTTP server shutdown error:", err)
+	}
+	if err := monitorService.Shutdown(shutdownCtx); err != nil {
+		appLogger.Error("Monitoring service shutdown error:", err)
+	}
+	workersDone := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-shutdownCtx.Done():
+		appLogger.Error("Background workers shutdown error:", shutdownCtx.Err())
+	}
+	memoryCache.Close()
+
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+
+	appLogger.Info("Weex Monitor application stopped")
+	if exitCode != 0 {
+		os.Exit(exitCode)
 	}
 }
 
@@ -125,15 +246,48 @@ func loadConfig(configFile string) (*config.Config, error) {
 	// 设置默认值
 	viper.SetDefault("server.port", "8080")
 	viper.SetDefault("server.mode", "debug")
+	viper.SetDefault("server.shutdown_timeout", "30s")
+	viper.SetDefault("server.trusted_proxies", []string{})
 	viper.SetDefault("database.charset", "utf8mb4")
 	viper.SetDefault("database.parse_time", true)
 	viper.SetDefault("database.loc", "Local")
 	viper.SetDefault("log.level", "info")
 	viper.SetDefault("log.format", "json")
 	viper.SetDefault("log.output", "both")
+	viper.SetDefault("weex.api_url", weex.DefaultBaseURL)
+	viper.SetDefault("weex.timeout", "30s")
+	viper.SetDefault("weex.retry_times", 3)
+	viper.SetDefault("weex.retry_base_delay", "500ms")
+	viper.SetDefault("weex.retry_max_delay", "10s")
+	viper.SetDefault("weex.page_size", 100)
+	viper.SetDefault("weex.rate_limit", 10)
+	viper.SetDefault("weex.rate_burst", 20)
 	viper.SetDefault("monitor.default_interval", "30s")
 	viper.SetDefault("monitor.max_goroutines", 100)
+	viper.SetDefault("monitor.queue_size", 1000)
+	viper.SetDefault("monitor.reconcile_max_attempts", 10)
+	viper.SetDefault("monitor.baseline_history_window", "168h")
+	viper.SetDefault("monitor.close_confirm_polls", 2)
+	viper.SetDefault("monitor.close_grace_period", "0s")
+	viper.SetDefault("monitor.suspicious_min_orders", 3)
+	viper.SetDefault("monitor.suspicious_confirm_polls", 5)
+	viper.SetDefault("auth.access_token_ttl", "2h")
+	viper.SetDefault("auth.refresh_token_ttl", "168h")
+	viper.SetDefault("auth.lockout.max_attempts", 5)
+	viper.SetDefault("auth.lockout.ip_max_attempts", 20)
+	viper.SetDefault("auth.lockout.window", "15m")
+	viper.SetDefault("auth.lockout.base_duration", "1m")
+	viper.SetDefault("auth.lockout.max_duration", "24h")
 	viper.SetDefault("notification.timeout", "10s")
+	viper.SetDefault("notification.retry.max_attempts", 5)
+	viper.SetDefault("notification.retry.interval", "30s")
+	viper.SetDefault("notification.retry.base_delay", "30s")
+	viper.SetDefault("notification.retry.max_delay", "30m")
+	viper.SetDefault("notification.retry.batch_size", 50)
+	viper.SetDefault("notification.email.port", 587)
+	viper.SetDefault("notification.email.starttls", true)
+	viper.SetDefault("notification.email.mode", "immediate")
+	viper.SetDefault("notification.email.digest_interval", "daily")
 
 	// 环境变量映射
 	viper.SetEnvPrefix("WEEX")
@@ -20,14 +20,17 @@ func (item CacheItem) IsExpired() bool {
 
 // MemoryCache 内存缓存实现
 type MemoryCache struct {
-	items map[string]CacheItem
-	mutex sync.RWMutex
+	items     map[string]CacheItem
+	mutex     sync.RWMutex
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 // NewMemoryCache 创建内存缓存
 func NewMemoryCache() *MemoryCache {
 	cache := &MemoryCache{
 		items: make(map[string]CacheItem),
+		done:  make(chan struct{}),
 	}
 
 	// 启动清理协程
@@ -80,17 +83,29 @@ func (c *MemoryCache) cleanup() {
 	ticker := time.NewTicker(5 * time.Minute) // 每5分钟清理一次
 	defer ticker.Stop()
 
-	for range ticker.C {
-		c.mutex.Lock()
-		for key, item := range c.items {
-			if item.IsExpired() {
-				delete(c.items, key)
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.mutex.Lock()
+			for key, item := range c.items {
+				if item.IsExpired() {
+					delete(c.items, key)
+				}
 			}
+			c.mutex.Unlock()
 		}
-		c.mutex.Unlock()
 	}
 }
 
+// Close 停止清理协程
+func (c *MemoryCache) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
 // Clear 清空所有缓存
 func (c *MemoryCache) Clear() {
 	c.mutex.Lock()
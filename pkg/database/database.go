@@ -70,9 +70,39 @@ func InitDB(config *Config) (*gorm.DB, error) {
 
 // autoMigrate 自动迁移数据表
 func autoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+	// 升级前已存在的交易员没有基线时间，需要在新增列后回填
+	backfillBaseline := db.Migrator().HasTable(&model.TraderMonitor{}) &&
+		!db.Migrator().HasColumn(&model.TraderMonitor{}, "BaselineAt")
+
+	if err := db.AutoMigrate(
 		&model.TraderMonitor{},
 		&model.OrderHistory{},
 		&model.NotificationLog{},
-	)
+		&model.OrderChangeEvent{},
+		&model.Subscriber{},
+		&model.Subscription{},
+		&model.NotificationRule{},
+		&model.User{},
+		&model.UserSession{},
+		&model.APIKey{},
+		&model.AuditLog{},
+		&model.LoginThrottle{},
+	); err != nil {
+		return err
+	}
+
+	if backfillBaseline {
+		if err := backfillTraderBaseline(db); err != nil {
+			return fmt.Errorf("failed to backfill trader baseline: %w", err)
+		}
+	}
+	return nil
+}
+
+// backfillTraderBaseline 已有订单记录的交易员视为已建立基线，基线时间取创建时间
+// 否则升级后的首次拉取会走基线流程，跳过这一轮的开仓通知和平仓检测，并同时导入所有交易员的历史订单
+func backfillTraderBaseline(db *gorm.DB) error {
+	return db.Exec(`UPDATE trader_monitors SET baseline_at = created_at
+		WHERE baseline_at IS NULL
+		AND EXISTS (SELECT 1 FROM order_history WHERE order_history.trader_user_id = trader_monitors.trader_user_id)`).Error
 }
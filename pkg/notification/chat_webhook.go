@@ -0,0 +1,103 @@
+package notification
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRetryAfter 平台要求的等待时间超过该值时不再重试，直接返回失败
+const maxRetryAfter = 30 * time.Second
+
+// pacer 保证同一 webhook 地址的两次请求之间至少间隔 interval，用于遵守平台的速率限制
+type pacer struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     map[string]time.Time
+}
+
+// newPacer 创建请求节流器
+func newPacer(interval time.Duration) *pacer {
+	return &pacer{
+		interval: interval,
+		next:     make(map[string]time.Time),
+	}
+}
+
+// wait 等待到允许向 key 发送下一次请求
+func (p *pacer) wait(key string) {
+	p.mu.Lock()
+	now := time.Now()
+	at := p.next[key]
+	if at.Before(now) {
+		at = now
+	}
+	p.next[key] = at.Add(p.interval)
+	p.mu.Unlock()
+
+	time.Sleep(time.Until(at))
+}
+
+// postChatWebhook 向聊天平台的 incoming webhook 发送 JSON，遇到 429 时按平台返回的等待时间重试一次
+// retryAfter 从响应中解析平台要求的等待时间
+func postChatWebhook(client *http.Client, pacer *pacer, url string, body []byte, retryAfter func(resp *http.Response, body []byte) time.Duration) error {
+	for attempt := 1; ; attempt++ {
+		pacer.wait(url)
+
+		resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			// 错误信息中的地址本身就是密钥，不能原样返回
+			return errors.New("failed to send webhook: " + strings.ReplaceAll(err.Error(), url, maskWebhookURL(url)))
+		}
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt == 1 {
+			wait := retryAfter(resp, respBody)
+			if wait <= maxRetryAfter {
+				time.Sleep(wait)
+				continue
+			}
+		}
+
+		return fmt.Errorf("webhook failed with status %d: %s", resp.StatusCode, truncateRunes(string(respBody), 256))
+	}
+}
+
+// retryAfterHeader 解析 Retry-After 响应头（秒），缺失时等待1秒
+func retryAfterHeader(resp *http.Response) time.Duration {
+	if seconds, err := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	return time.Second
+}
+
+// maskWebhookURL 隐藏 webhook 地址中的密钥部分，用于错误信息
+func maskWebhookURL(url string) string {
+	if idx := strings.LastIndex(url, "/"); idx > 0 && idx < len(url)-1 {
+		return url[:idx+1] + "***"
+	}
+	return url
+}
+
+// truncateRunes 按字符截断字符串，超长时以省略号结尾
+func truncateRunes(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	if limit <= 1 {
+		return string(runes[:limit])
+	}
+	return string(runes[:limit-1]) + "…"
+}
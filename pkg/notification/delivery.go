@@ -0,0 +1,67 @@
+package notification
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// DeliveryError 消息只送达了一部分接收地址或分段，Delivered 为本次新送达的部分
+// 重试时把它们放入 NotificationMessage.Delivered，已送达的部分不会重复发送
+type DeliveryError struct {
+	Delivered []string
+	Err       error
+}
+
+// Error 实现 error 接口
+func (e *DeliveryError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// deliveryTracker 记录一次发送中每个接收地址的每个分段是否已送达
+type deliveryTracker struct {
+	done      map[string]bool
+	delivered []string
+}
+
+// newDeliveryTracker 创建送达记录，跳过消息中已送达的部分
+func newDeliveryTracker(notification NotificationMessage) *deliveryTracker {
+	done := make(map[string]bool, len(notification.Delivered))
+	for _, key := range notification.Delivered {
+		done[key] = true
+	}
+	return &deliveryTracker{done: done}
+}
+
+// send 发送接收地址 target 的第 part 段，已送达时直接跳过
+func (t *deliveryTracker) send(target string, part int, send func() error) error {
+	key := deliveryKey(target, part)
+	if t.done[key] {
+		return nil
+	}
+	if err := send(); err != nil {
+		return err
+	}
+	t.done[key] = true
+	t.delivered = append(t.delivered, key)
+	return nil
+}
+
+// result 包装发送结果，有部分送达时返回 *DeliveryError
+func (t *deliveryTracker) result(err error) error {
+	if err == nil || len(t.delivered) == 0 {
+		return err
+	}
+	return &DeliveryError{Delivered: t.delivered, Err: err}
+}
+
+// deliveryKey 接收地址和分段的标识，地址可能包含密钥（如 webhook 地址），只保存其哈希
+func deliveryKey(target string, part int) string {
+	sum := sha256.Sum256([]byte(target))
+	return hex.EncodeToString(sum[:8]) + ":" + strconv.Itoa(part)
+}
@@ -0,0 +1,241 @@
+package notification
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"weex-watchdog/internal/model"
+)
+
+// Discord 平台限制
+const (
+	discordMaxContent      = 2000
+	discordMaxEmbeds       = 10
+	discordMaxEmbedChars   = 6000 // 单条消息所有 embed 的字符总数
+	discordMaxTitle        = 256
+	discordMaxFields       = 25
+	discordMaxFieldName    = 256
+	discordMaxFieldValue   = 1024
+	discordMaxDescription  = 4096
+	discordWebhookInterval = 400 * time.Millisecond // 每个 webhook 约每2秒5次请求
+	discordColorLong       = 0x28a745
+	discordColorShort      = 0xdc3545
+)
+
+// discordEmbedField embed 字段
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// discordEmbed Discord embed
+type discordEmbed struct {
+	Title       string              `json:"title,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Color       int                 `json:"color,omitempty"`
+	Fields      []discordEmbedField `json:"fields,omitempty"`
+	Timestamp   string              `json:"timestamp,omitempty"`
+}
+
+// chars embed 计入总长度限制的字符数
+func (e discordEmbed) chars() int {
+	n := utf8.RuneCountInString(e.Title) + utf8.RuneCountInString(e.Description)
+	for _, field := range e.Fields {
+		n += utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+	}
+	return n
+}
+
+// discordPayload Discord webhook 请求体
+type discordPayload struct {
+	Username string         `json:"username,omitempty"`
+	Content  string         `json:"content,omitempty"`
+	Embeds   []discordEmbed `json:"embeds,omitempty"`
+}
+
+// DiscordNotificationClient Discord webhook 通知服务实现
+// 消息内容为 JSON 序列化的请求体列表，超出单条消息限制的 embed 拆分为多条消息
+type DiscordNotificationClient struct {
+	webhookURLs []string
+	username    string
+	client      *http.Client
+	pacer       *pacer
+}
+
+// NewDiscordNotificationClient 创建 Discord 通知服务
+func NewDiscordNotificationClient(config *Config) *DiscordNotificationClient {
+	timeout := config.Discord.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &DiscordNotificationClient{
+		webhookURLs: config.Discord.WebhookURLs,
+		username:    config.Discord.Username,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		pacer: newPacer(discordWebhookInterval),
+	}
+}
+
+// BuildNotificationMessage 构建通知消息，每个币种和方向的分组生成一个 embed
+func (s *DiscordNotificationClient) BuildNotificationMessage(orders []*model.OrderHistory, isOpen bool) string {
+	if len(orders) == 0 {
+		return ""
+	}
+
+	actionText := "新开仓"
+	if !isOpen {
+		actionText = "平仓"
+	}
+	header := fmt.Sprintf("%s **%s提醒** | 交易员: %s", getActionIcon(isOpen), actionText, orders[0].TraderName)
+
+	var embeds []discordEmbed
+	for _, group := range GroupOrders(orders) {
+		color := discordColorShort
+		if group.IsLong() {
+			color = discordColorLong
+		}
+		title := truncateRunes(fmt.Sprintf("%s %s (%d单)", group.Symbol, directionText(group.PositionSide), len(group.Orders)), discordMaxTitle)
+
+		embed := discordEmbed{Title: title, Color: color}
+		for _, order := range group.Orders {
+			// 单个 embed 字段数有限，超出时拆到下一个 embed
+			if len(embed.Fields) == discordMaxFields {
+				embeds = append(embeds, embed)
+				embed = discordEmbed{Title: title, Color: color}
+			}
+			embed.Fields = append(embed.Fields, discordOrderField(order, isOpen))
+		}
+		embeds = append(embeds, embed)
+	}
+
+	return marshalDiscordPayloads(s.batchEmbeds(header, embeds))
+}
+
+// discordOrderField 单个订单的 embed 字段
+func discordOrderField(order *model.OrderHistory, isOpen bool) discordEmbedField {
+	value := fmt.Sprintf("杠杆: %s\n价格: %s", order.OpenLeverage, order.OpenPrice)
+	if !isOpen && order.RealizedPnl != "" {
+		value += fmt.Sprintf("\n平仓价: %s\n盈亏: **%s**\n手续费: %s\n方式: %s",
+			displayValue(order.AverageClosePrice), order.RealizedPnl, displayValue(order.CloseFee), closedByLabel(order.ClosedBy))
+	}
+
+	return discordEmbedField{
+		Name:   truncateRunes("时间 "+orderEventTime(order, isOpen), discordMaxFieldName),
+		Value:  truncateRunes(value, discordMaxFieldValue),
+		Inline: true,
+	}
+}
+
+// BuildOrderChangeMessage 构建持仓变化通知消息
+func (s *DiscordNotificationClient) BuildOrderChangeMessage(changes []*model.OrderChangeEvent) string {
+	if len(changes) == 0 {
+		return ""
+	}
+
+	header := fmt.Sprintf("🔄 **持仓变化提醒** | 交易员: %s", changes[0].TraderName)
+
+	embeds := make([]discordEmbed, 0, len(changes))
+	for _, change := range changes {
+		color := discordColorShort
+		if change.PositionSide == "LONG" {
+			color = discordColorLong
+		}
+		embeds = append(embeds, discordEmbed{
+			Title:       truncateRunes(fmt.Sprintf("%s %s · %s", change.ContractSymbol, directionText(change.PositionSide), change.ChangeType.Label()), discordMaxTitle),
+			Description: truncateRunes(fmt.Sprintf("%s → %s", displayValue(change.OldValue), displayValue(change.NewValue)), discordMaxDescription),
+			Color:       color,
+			Timestamp:   change.DetectedAt.Format(time.RFC3339),
+		})
+	}
+
+	return marshalDiscordPayloads(s.batchEmbeds(header, embeds))
+}
+
+// batchEmbeds 将 embed 按数量和总字符数限制拆分为多条消息，标题只放在第一条
+func (s *DiscordNotificationClient) batchEmbeds(header string, embeds []discordEmbed) []discordPayload {
+	payloads := []discordPayload{{
+		Username: s.username,
+		Content:  truncateRunes(header, discordMaxContent),
+	}}
+
+	chars := 0
+	for _, embed := range embeds {
+		current := &payloads[len(payloads)-1]
+		size := embed.chars()
+		if len(current.Embeds) == discordMaxEmbeds || (len(current.Embeds) > 0 && chars+size > discordMaxEmbedChars) {
+			payloads = append(payloads, discordPayload{Username: s.username})
+			current = &payloads[len(payloads)-1]
+			chars = 0
+		}
+		current.Embeds = append(current.Embeds, embed)
+		chars += size
+	}
+
+	return payloads
+}
+
+// marshalDiscordPayloads 序列化请求体列表
+func marshalDiscordPayloads(payloads []discordPayload) string {
+	data, err := json.Marshal(payloads)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// SendMessage 将消息发送到所有 webhook，单个 webhook 失败不影响其他 webhook
+func (s *DiscordNotificationClient) SendMessage(notification NotificationMessage) error {
+	webhookURLs := notification.targets(s.webhookURLs)
+	if len(webhookURLs) == 0 {
+		return errors.New("discord configuration is incomplete")
+	}
+	if notification.Message == "" {
+		return errors.New("notification message cannot be empty")
+	}
+
+	var payloads []discordPayload
+	if err := json.Unmarshal([]byte(notification.Message), &payloads); err != nil || len(payloads) == 0 {
+		// 纯文本消息（如测试消息）按行拆分后作为 content 发送
+		payloads = nil
+		for _, chunk := range splitMessage(notification.Message, discordMaxContent) {
+			payloads = append(payloads, discordPayload{Username: s.username, Content: chunk})
+		}
+	}
+
+	// 重试时跳过已送达的地址和分段
+	tracker := newDeliveryTracker(notification)
+	var errs []error
+	for _, url := range webhookURLs {
+		for i, payload := range payloads {
+			body, err := json.Marshal(payload)
+			if err != nil {
+				return tracker.result(fmt.Errorf("failed to marshal discord payload: %w", err))
+			}
+			if err := tracker.send(url, i, func() error { return postChatWebhook(s.client, s.pacer, url, body, discordRetryAfter) }); err != nil {
+				errs = append(errs, fmt.Errorf("discord webhook %s: %w", maskWebhookURL(url), err))
+				break
+			}
+		}
+	}
+
+	return tracker.result(errors.Join(errs...))
+}
+
+// discordRetryAfter 解析 429 响应中的 retry_after（秒）
+func discordRetryAfter(resp *http.Response, body []byte) time.Duration {
+	var result struct {
+		RetryAfter float64 `json:"retry_after"`
+	}
+	if err := json.Unmarshal(body, &result); err == nil && result.RetryAfter > 0 {
+		return time.Duration(result.RetryAfter * float64(time.Second))
+	}
+	return retryAfterHeader(resp)
+}
@@ -0,0 +1,524 @@
+package notification
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"weex-watchdog/internal/model"
+)
+
+// 邮件发送模式
+const (
+	EmailModeImmediate = "immediate" // 每个事件立即发送
+	EmailModeDigest    = "digest"    // 只发送定期汇总
+	EmailModeBoth      = "both"      // 两者都发送
+)
+
+// 汇总周期
+const (
+	DigestHourly = "hourly"
+	DigestDaily  = "daily"
+)
+
+// emailContent 渲染后的邮件内容，序列化后作为通知消息
+type emailContent struct {
+	TraderUserID string `json:"trader_user_id"`
+	Subject      string `json:"subject"`
+	Text         string `json:"text"`
+	HTML         string `json:"html"`
+}
+
+// EmailNotificationClient SMTP 邮件通知服务实现，支持 STARTTLS 和认证
+type EmailNotificationClient struct {
+	host             string
+	port             int
+	username         string
+	password         string
+	from             string
+	to               []string
+	traderRecipients map[string][]string
+	startTLS         bool
+	mode             string
+	digestInterval   string
+	timeout          time.Duration
+}
+
+// NewEmailNotificationClient 创建邮件通知服务
+func NewEmailNotificationClient(config *Config) *EmailNotificationClient {
+	port := config.Email.Port
+	if port <= 0 {
+		port = 587
+	}
+
+	mode := strings.ToLower(config.Email.Mode)
+	if mode == "" {
+		mode = EmailModeImmediate
+	}
+
+	digestInterval := strings.ToLower(config.Email.DigestInterval)
+	if digestInterval == "" {
+		digestInterval = DigestDaily
+	}
+
+	timeout := config.Email.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	from := config.Email.From
+	if from == "" {
+		from = config.Email.Username
+	}
+
+	return &EmailNotificationClient{
+		host:             config.Email.Host,
+		port:             port,
+		username:         config.Email.Username,
+		password:         config.Email.Password,
+		from:             from,
+		to:               config.Email.To,
+		traderRecipients: config.Email.TraderRecipients,
+		startTLS:         config.Email.StartTLS,
+		mode:             mode,
+		digestInterval:   digestInterval,
+		timeout:          timeout,
+	}
+}
+
+// ImmediateEnabled 是否逐条发送事件邮件
+func (s *EmailNotificationClient) ImmediateEnabled() bool {
+	return s.mode == EmailModeImmediate || s.mode == EmailModeBoth
+}
+
+// DigestEnabled 是否发送定期汇总
+func (s *EmailNotificationClient) DigestEnabled() bool {
+	return s.mode == EmailModeDigest || s.mode == EmailModeBoth
+}
+
+// DigestInterval 汇总周期
+func (s *EmailNotificationClient) DigestInterval() time.Duration {
+	if s.digestInterval == DigestHourly {
+		return time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// BuildNotificationMessage 构建通知邮件，仅汇总模式下返回空
+func (s *EmailNotificationClient) BuildNotificationMessage(orders []*model.OrderHistory, isOpen bool) string {
+	if len(orders) == 0 || !s.ImmediateEnabled() {
+		return ""
+	}
+
+	actionText := "新开仓"
+	if !isOpen {
+		actionText = "平仓"
+	}
+
+	var text, body strings.Builder
+	fmt.Fprintf(&text, "%s提醒\n交易员: %s (%s)\n", actionText, orders[0].TraderName, orders[0].TraderUserID)
+	fmt.Fprintf(&body, `<h3 style="margin: 0 0 6px 0;">%s %s提醒</h3>
+<p style="margin: 0 0 8px 0;">交易员: <b>%s</b> (%s)</p>
+`, getActionIcon(isOpen), actionText, html.EscapeString(orders[0].TraderName), html.EscapeString(orders[0].TraderUserID))
+
+	for _, group := range GroupOrders(orders) {
+		fmt.Fprintf(&text, "\n%s %s (%d单)\n", group.Symbol, directionText(group.PositionSide), len(group.Orders))
+		fmt.Fprintf(&body, `<h4 style="margin: 10px 0 4px 0;">%s <span style="color: %s;">%s</span> (%d单)</h4>
+<table cellpadding="4" cellspacing="0" border="1" style="border-collapse: collapse; font-size: 13px;">
+<tr><th>时间</th><th>杠杆</th><th>开仓价</th>%s</tr>
+`, html.EscapeString(group.Symbol), emailDirectionColor(group.PositionSide), directionText(group.PositionSide), len(group.Orders), emailCloseHeader(isOpen))
+
+		for _, order := range group.Orders {
+			eventTime := orderEventTime(order, isOpen)
+			fmt.Fprintf(&text, "- 时间 %s | 杠杆 %s | 开仓价 %s", eventTime, order.OpenLeverage, order.OpenPrice)
+			fmt.Fprintf(&body, "<tr><td>%s</td><td>%s</td><td>%s</td>", eventTime, html.EscapeString(order.OpenLeverage), html.EscapeString(order.OpenPrice))
+			if !isOpen {
+				fmt.Fprintf(&text, " | 平仓价 %s | 盈亏 %s | 手续费 %s | 方式 %s",
+					displayValue(order.AverageClosePrice), displayValue(order.RealizedPnl), displayValue(order.CloseFee), closedByLabel(order.ClosedBy))
+				fmt.Fprintf(&body, "<td>%s</td><td>%s</td><td>%s</td><td>%s</td>",
+					html.EscapeString(displayValue(order.AverageClosePrice)), html.EscapeString(displayValue(order.RealizedPnl)),
+					html.EscapeString(displayValue(order.CloseFee)), html.EscapeString(closedByLabel(order.ClosedBy)))
+			}
+			text.WriteString("\n")
+			body.WriteString("</tr>\n")
+		}
+		body.WriteString("</table>\n")
+	}
+
+	return marshalEmailContent(&emailContent{
+		TraderUserID: orders[0].TraderUserID,
+		Subject:      fmt.Sprintf("[Weex] %s %s提醒 (%d单)", orders[0].TraderName, actionText, len(orders)),
+		Text:         text.String(),
+		HTML:         body.String(),
+	})
+}
+
+// BuildOrderChangeMessage 构建持仓变化通知邮件，仅汇总模式下返回空
+func (s *EmailNotificationClient) BuildOrderChangeMessage(changes []*model.OrderChangeEvent) string {
+	if len(changes) == 0 || !s.ImmediateEnabled() {
+		return ""
+	}
+
+	var text, body strings.Builder
+	fmt.Fprintf(&text, "持仓变化提醒\n交易员: %s (%s)\n\n", changes[0].TraderName, changes[0].TraderUserID)
+	fmt.Fprintf(&body, `<h3 style="margin: 0 0 6px 0;">🔄 持仓变化提醒</h3>
+<p style="margin: 0 0 8px 0;">交易员: <b>%s</b> (%s)</p>
+<table cellpadding="4" cellspacing="0" border="1" style="border-collapse: collapse; font-size: 13px;">
+<tr><th>时间</th><th>币种</th><th>方向</th><th>变化</th><th>原值</th><th>新值</th></tr>
+`, html.EscapeString(changes[0].TraderName), html.EscapeString(changes[0].TraderUserID))
+
+	for _, change := range changes {
+		eventTime := change.DetectedAt.Format("15:04:05")
+		fmt.Fprintf(&text, "- %s %s %s %s: %s → %s\n", eventTime, change.ContractSymbol, directionText(change.PositionSide),
+			change.ChangeType.Label(), displayValue(change.OldValue), displayValue(change.NewValue))
+		fmt.Fprintf(&body, `<tr><td>%s</td><td>%s</td><td style="color: %s;">%s</td><td>%s</td><td>%s</td><td>%s</td></tr>
+`, eventTime, html.EscapeString(change.ContractSymbol), emailDirectionColor(change.PositionSide), directionText(change.PositionSide),
+			change.ChangeType.Label(), html.EscapeString(displayValue(change.OldValue)), html.EscapeString(displayValue(change.NewValue)))
+	}
+	body.WriteString("</table>\n")
+
+	return marshalEmailContent(&emailContent{
+		TraderUserID: changes[0].TraderUserID,
+		Subject:      fmt.Sprintf("[Weex] %s 持仓变化提醒 (%d项)", changes[0].TraderName, len(changes)),
+		Text:         text.String(),
+		HTML:         body.String(),
+	})
+}
+
+// SendDigest 发送时间段内的开平仓汇总，默认收件人收到所有交易员，按交易员配置的收件人只收到对应交易员
+func (s *EmailNotificationClient) SendDigest(orders []model.OrderHistory, from, to time.Time) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	// 按收件人归集订单
+	byRecipient := make(map[string][]model.OrderHistory)
+	for _, order := range orders {
+		for _, recipient := range s.recipients(order.TraderUserID) {
+			byRecipient[recipient] = append(byRecipient[recipient], order)
+		}
+	}
+
+	recipients := make([]string, 0, len(byRecipient))
+	for recipient := range byRecipient {
+		recipients = append(recipients, recipient)
+	}
+	sort.Strings(recipients)
+
+	var errs []error
+	for _, recipient := range recipients {
+		subject, text, body := buildDigest(byRecipient[recipient], from, to)
+		if err := s.send([]string{recipient}, subject, text, body); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", recipient, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// buildDigest 渲染汇总邮件，按交易员统计开仓数、平仓数和已实现盈亏
+func buildDigest(orders []model.OrderHistory, from, to time.Time) (subject, text, body string) {
+	type traderDigest struct {
+		name   string
+		opened []model.OrderHistory
+		closed []model.OrderHistory
+		pnl    float64
+	}
+
+	digests := make(map[string]*traderDigest)
+	var traderIDs []string
+	for _, order := range orders {
+		digest, ok := digests[order.TraderUserID]
+		if !ok {
+			digest = &traderDigest{name: order.TraderName}
+			digests[order.TraderUserID] = digest
+			traderIDs = append(traderIDs, order.TraderUserID)
+		}
+		if !order.FirstSeenAt.Before(from) && order.FirstSeenAt.Before(to) {
+			digest.opened = append(digest.opened, order)
+		}
+		if order.ClosedAt != nil && !order.ClosedAt.Before(from) && order.ClosedAt.Before(to) {
+			digest.closed = append(digest.closed, order)
+			if pnl, err := strconv.ParseFloat(order.RealizedPnl, 64); err == nil {
+				digest.pnl += pnl
+			}
+		}
+	}
+
+	period := fmt.Sprintf("%s ~ %s", from.Format("2006-01-02 15:04"), to.Format("2006-01-02 15:04"))
+	subject = fmt.Sprintf("[Weex] 交易汇总 %s", period)
+
+	var t, h strings.Builder
+	fmt.Fprintf(&t, "交易汇总 %s\n", period)
+	fmt.Fprintf(&h, `<h3 style="margin: 0 0 8px 0;">📊 交易汇总</h3>
+<p style="margin: 0 0 8px 0; color: #666;">%s</p>
+`, period)
+
+	for _, traderID := range traderIDs {
+		digest := digests[traderID]
+		fmt.Fprintf(&t, "\n交易员: %s (%s) 开仓 %d 单，平仓 %d 单，已实现盈亏 %.4f\n",
+			digest.name, traderID, len(digest.opened), len(digest.closed), digest.pnl)
+		fmt.Fprintf(&h, `<h4 style="margin: 12px 0 4px 0;">交易员: %s (%s)</h4>
+<p style="margin: 0 0 4px 0;">开仓 %d 单，平仓 %d 单，已实现盈亏 <b style="color: %s;">%.4f</b></p>
+<table cellpadding="4" cellspacing="0" border="1" style="border-collapse: collapse; font-size: 13px;">
+<tr><th>事件</th><th>时间</th><th>币种</th><th>方向</th><th>杠杆</th><th>开仓价</th><th>平仓价</th><th>盈亏</th></tr>
+`, html.EscapeString(digest.name), html.EscapeString(traderID), len(digest.opened), len(digest.closed), emailPnlColor(digest.pnl), digest.pnl)
+
+		for _, order := range digest.opened {
+			fmt.Fprintf(&t, "- 开仓 %s %s %s 杠杆 %s 开仓价 %s\n", order.FirstSeenAt.Format("01-02 15:04"),
+				order.ContractSymbol, directionText(order.PositionSide), order.OpenLeverage, order.OpenPrice)
+			fmt.Fprintf(&h, "<tr><td>开仓</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>-</td><td>-</td></tr>\n",
+				order.FirstSeenAt.Format("01-02 15:04"), html.EscapeString(order.ContractSymbol), directionText(order.PositionSide),
+				html.EscapeString(order.OpenLeverage), html.EscapeString(order.OpenPrice))
+		}
+		for _, order := range digest.closed {
+			fmt.Fprintf(&t, "- 平仓 %s %s %s 杠杆 %s 开仓价 %s 平仓价 %s 盈亏 %s\n", order.ClosedAt.Format("01-02 15:04"),
+				order.ContractSymbol, directionText(order.PositionSide), order.OpenLeverage, order.OpenPrice,
+				displayValue(order.AverageClosePrice), displayValue(order.RealizedPnl))
+			fmt.Fprintf(&h, "<tr><td>平仓</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+				order.ClosedAt.Format("01-02 15:04"), html.EscapeString(order.ContractSymbol), directionText(order.PositionSide),
+				html.EscapeString(order.OpenLeverage), html.EscapeString(order.OpenPrice),
+				html.EscapeString(displayValue(order.AverageClosePrice)), html.EscapeString(displayValue(order.RealizedPnl)))
+		}
+		h.WriteString("</table>\n")
+	}
+
+	return subject, t.String(), h.String()
+}
+
+// SendMessage 发送通知邮件，收件人为默认收件人加上交易员对应的收件人
+func (s *EmailNotificationClient) SendMessage(notification NotificationMessage) error {
+	if notification.Message == "" {
+		return errors.New("notification message cannot be empty")
+	}
+
+	var content emailContent
+	if err := json.Unmarshal([]byte(notification.Message), &content); err != nil || content.Subject == "" {
+		// 模板渲染的消息或纯文本消息（如测试消息）
+		content = emailContent{
+			Subject: "[Weex] " + notification.Type,
+			Text:    notification.Message,
+			HTML:    "<pre>" + html.EscapeString(notification.Message) + "</pre>",
+		}
+		if strings.HasPrefix(strings.TrimSpace(notification.Message), "<") {
+			content.Text = stripTags(notification.Message)
+			content.HTML = notification.Message
+		}
+	}
+
+	// 模板渲染的消息中没有交易员ID，使用事件所属的交易员
+	traderUserID := content.TraderUserID
+	if traderUserID == "" {
+		traderUserID = notification.TraderUserID
+	}
+	to := s.recipients(traderUserID)
+	if notification.To != "" {
+		to = []string{notification.To}
+	}
+	return s.send(to, content.Subject, content.Text, content.HTML)
+}
+
+// recipients 默认收件人加上交易员对应的收件人，去重
+func (s *EmailNotificationClient) recipients(traderUserID string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	add := func(addresses []string) {
+		for _, address := range addresses {
+			address = strings.TrimSpace(address)
+			if address != "" && !seen[strings.ToLower(address)] {
+				seen[strings.ToLower(address)] = true
+				result = append(result, address)
+			}
+		}
+	}
+	add(s.to)
+	if traderUserID != "" {
+		add(s.traderRecipients[traderUserID])
+	}
+	return result
+}
+
+// send 通过 SMTP 发送 multipart/alternative 邮件
+func (s *EmailNotificationClient) send(to []string, subject, text, body string) error {
+	if s.host == "" || s.from == "" {
+		return errors.New("email configuration is incomplete")
+	}
+	if len(to) == 0 {
+		return errors.New("email has no recipients")
+	}
+
+	message, err := buildMIMEMessage(s.from, to, subject, text, body)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(s.host, strconv.Itoa(s.port))
+	conn, err := net.DialTimeout("tcp", addr, s.timeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	// 整个会话共用一个超时，防止服务器无响应时卡住分发
+	conn.SetDeadline(time.Now().Add(s.timeout))
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to create SMTP client: %w", err)
+	}
+	defer client.Close()
+
+	if s.startTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("SMTP server does not support STARTTLS")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+
+	if s.username != "" {
+		// PlainAuth 只允许在 TLS 连接或本机地址上发送密码
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(s.from); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM failed: %w", err)
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("SMTP RCPT TO %s failed: %w", recipient, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA failed: %w", err)
+	}
+	if _, err := w.Write(message); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write email body: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// buildMIMEMessage 构建包含纯文本和 HTML 两个部分的邮件
+func buildMIMEMessage(from string, to []string, subject, text, body string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	headers := []string{
+		"From: " + from,
+		"To: " + strings.Join(to, ", "),
+		"Subject: " + mime.BEncoding.Encode("UTF-8", subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: " + newMessageID(from),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + writer.Boundary(),
+	}
+	var message bytes.Buffer
+	message.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", text},
+		{"text/html; charset=UTF-8", `<html><body style="font-family: sans-serif;">` + body + "</body></html>"},
+	}
+	for _, part := range parts {
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create email part: %w", err)
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("failed to encode email part: %w", err)
+		}
+		if err := qp.Close(); err != nil {
+			return nil, fmt.Errorf("failed to encode email part: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish email body: %w", err)
+	}
+
+	message.Write(buf.Bytes())
+	return message.Bytes(), nil
+}
+
+// newMessageID 生成邮件 Message-ID
+func newMessageID(from string) string {
+	domain := "localhost"
+	if idx := strings.LastIndex(from, "@"); idx >= 0 {
+		domain = strings.TrimRight(from[idx+1:], ">")
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(b), domain)
+}
+
+// tagPattern HTML 标签
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// stripTags 去掉 HTML 标签，用于生成 HTML 邮件的纯文本部分
+func stripTags(s string) string {
+	return html.UnescapeString(tagPattern.ReplaceAllString(s, ""))
+}
+
+// marshalEmailContent 序列化邮件内容
+func marshalEmailContent(content *emailContent) string {
+	data, err := json.Marshal(content)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// emailCloseHeader 平仓邮件额外的表头
+func emailCloseHeader(isOpen bool) string {
+	if isOpen {
+		return ""
+	}
+	return "<th>平仓价</th><th>盈亏</th><th>手续费</th><th>方式</th>"
+}
+
+// emailDirectionColor 方向颜色
+func emailDirectionColor(positionSide string) string {
+	if positionSide == "LONG" {
+		return "#28a745"
+	}
+	return "#dc3545"
+}
+
+// emailPnlColor 盈亏颜色
+func emailPnlColor(pnl float64) string {
+	if pnl < 0 {
+		return "#dc3545"
+	}
+	return "#28a745"
+}
@@ -0,0 +1,413 @@
+package notification
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"weex-watchdog/internal/model"
+)
+
+// fakeSMTPSession 假 SMTP 服务器收到的一封邮件
+type fakeSMTPSession struct {
+	auth     string
+	from     string
+	rcpt     []string
+	data     []byte
+	startTLS bool
+	err      error
+}
+
+// startFakeSMTP 启动假 SMTP 服务器，每个连接结束后把会话内容发送到 result，extensions 为 EHLO 中声明的扩展
+func startFakeSMTP(t *testing.T, extensions ...string) (host string, port int, result <-chan *fakeSMTPSession) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var tlsConfig *tls.Config
+	for _, ext := range extensions {
+		if ext == "STARTTLS" {
+			tlsConfig = &tls.Config{Certificates: []tls.Certificate{selfSignedCert(t)}}
+		}
+	}
+
+	sessions := make(chan *fakeSMTPSession, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			sessions <- serveFakeSMTP(conn, tlsConfig, extensions)
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, sessions
+}
+
+// serveFakeSMTP 处理一个 SMTP 会话
+func serveFakeSMTP(conn net.Conn, tlsConfig *tls.Config, extensions []string) *fakeSMTPSession {
+	session := &fakeSMTPSession{}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 fake ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			session.err = err
+			return session
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			lines := append([]string{"fake"}, extensions...)
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				tp.PrintfLine("250%s%s", sep, l)
+			}
+		case "STARTTLS":
+			tp.PrintfLine("220 ready to start TLS")
+			session.startTLS = true
+			tlsConn := tls.Server(conn, tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				session.err = err
+				return session
+			}
+			conn = tlsConn
+			tp = textproto.NewConn(conn)
+		case "AUTH":
+			session.auth = arg
+			tp.PrintfLine("235 2.7.0 authenticated")
+		case "MAIL":
+			session.from = arg
+			tp.PrintfLine("250 ok")
+		case "RCPT":
+			session.rcpt = append(session.rcpt, arg)
+			tp.PrintfLine("250 ok")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				session.err = err
+				return session
+			}
+			session.data = data
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return session
+		default:
+			tp.PrintfLine("502 unknown command")
+		}
+	}
+}
+
+// selfSignedCert 生成假服务器使用的自签名证书
+func selfSignedCert(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fake smtp"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// newTestEmailClient 创建连接假服务器的邮件客户端
+func newTestEmailClient(host string, port int, startTLS bool, username, password string) *EmailNotificationClient {
+	config := &Config{}
+	config.Email.Host = host
+	config.Email.Port = port
+	config.Email.Username = username
+	config.Email.Password = password
+	config.Email.From = "watchdog@example.com"
+	config.Email.StartTLS = startTLS
+	config.Email.Timeout = 5 * time.Second
+	return NewEmailNotificationClient(config)
+}
+
+// waitSession 等待假服务器结束一个会话
+func waitSession(t *testing.T, sessions <-chan *fakeSMTPSession) *fakeSMTPSession {
+	t.Helper()
+	select {
+	case session := <-sessions:
+		return session
+	case <-time.After(5 * time.Second):
+		t.Fatal("fake SMTP server did not finish")
+		return nil
+	}
+}
+
+func TestEmailSendBuildsMultipartMessage(t *testing.T) {
+	host, port, sessions := startFakeSMTP(t, "AUTH PLAIN")
+	client := newTestEmailClient(host, port, false, "user", "secret")
+
+	subject := "[Weex] 交易员 新开仓提醒"
+	text := "新开仓提醒\n交易员: 示例 = 100%"
+	body := "<p>交易员: <b>示例</b></p>"
+	if err := client.send([]string{"a@example.com", "b@example.com"}, subject, text, body); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	session := waitSession(t, sessions)
+	if session.err != nil {
+		t.Fatalf("server: %v", session.err)
+	}
+
+	wantAuth := "PLAIN " + base64.StdEncoding.EncodeToString([]byte("\x00user\x00secret"))
+	if session.auth != wantAuth {
+		t.Errorf("auth = %q, want %q", session.auth, wantAuth)
+	}
+	if session.from != "FROM:<watchdog@example.com>" {
+		t.Errorf("mail from = %q", session.from)
+	}
+	if len(session.rcpt) != 2 || session.rcpt[0] != "TO:<a@example.com>" || session.rcpt[1] != "TO:<b@example.com>" {
+		t.Errorf("rcpt = %q", session.rcpt)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(session.data)))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	decodedSubject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || decodedSubject != subject {
+		t.Errorf("subject = %q (%v), want %q", decodedSubject, err, subject)
+	}
+	if got := msg.Header.Get("To"); got != "a@example.com, b@example.com" {
+		t.Errorf("to header = %q", got)
+	}
+	if msg.Header.Get("Message-ID") == "" || msg.Header.Get("Date") == "" {
+		t.Error("missing Message-ID or Date header")
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("content type = %q (%v)", mediaType, err)
+	}
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	parts := make(map[string]string)
+	for {
+		// NextPart 会自动解码 quoted-printable，这里读取原始内容以验证编码本身
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next part: %v", err)
+		}
+		if enc := part.Header.Get("Content-Transfer-Encoding"); enc != "quoted-printable" {
+			t.Errorf("transfer encoding = %q", enc)
+		}
+		raw, err := io.ReadAll(quotedprintable.NewReader(part))
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[contentType] = string(raw)
+	}
+
+	if parts["text/plain"] != text {
+		t.Errorf("text part = %q, want %q", parts["text/plain"], text)
+	}
+	if !strings.Contains(parts["text/html"], body) || !strings.HasPrefix(parts["text/html"], "<html>") {
+		t.Errorf("html part = %q", parts["text/html"])
+	}
+}
+
+func TestEmailSendRequiresSTARTTLS(t *testing.T) {
+	host, port, sessions := startFakeSMTP(t)
+	client := newTestEmailClient(host, port, true, "user", "secret")
+
+	err := client.send([]string{"a@example.com"}, "subject", "text", "<p>html</p>")
+	if err == nil || !strings.Contains(err.Error(), "does not support STARTTLS") {
+		t.Fatalf("send error = %v, want STARTTLS unsupported", err)
+	}
+
+	session := waitSession(t, sessions)
+	if session.auth != "" || len(session.rcpt) != 0 {
+		t.Errorf("credentials or mail sent without TLS: auth=%q rcpt=%q", session.auth, session.rcpt)
+	}
+}
+
+func TestEmailSendVerifiesSTARTTLSCertificate(t *testing.T) {
+	host, port, sessions := startFakeSMTP(t, "STARTTLS", "AUTH PLAIN")
+	client := newTestEmailClient(host, port, true, "user", "secret")
+
+	// 自签名证书不受信任，握手必须失败，不能降级为明文发送
+	err := client.send([]string{"a@example.com"}, "subject", "text", "<p>html</p>")
+	if err == nil || !strings.Contains(err.Error(), "failed to start TLS") {
+		t.Fatalf("send error = %v, want TLS failure", err)
+	}
+
+	session := waitSession(t, sessions)
+	if !session.startTLS {
+		t.Error("client did not issue STARTTLS")
+	}
+	if session.auth != "" || len(session.rcpt) != 0 {
+		t.Errorf("credentials or mail sent without TLS: auth=%q rcpt=%q", session.auth, session.rcpt)
+	}
+}
+
+func TestEmailSendDigestGroupsByRecipient(t *testing.T) {
+	host, port, sessions := startFakeSMTP(t)
+	client := newTestEmailClient(host, port, false, "", "")
+	client.traderRecipients = map[string][]string{"2": {"trader2@example.com"}}
+	client.to = []string{"ops@example.com"}
+
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	to := from.Add(24 * time.Hour)
+	orders := []model.OrderHistory{
+		{TraderUserID: "1", TraderName: "one", FirstSeenAt: from},
+		{TraderUserID: "2", TraderName: "two", FirstSeenAt: from.Add(time.Hour)},
+	}
+
+	if err := client.SendDigest(orders, from, to); err != nil {
+		t.Fatalf("SendDigest: %v", err)
+	}
+
+	// 收件人按字母序发送：ops@ 收到所有交易员，trader2@ 只收到交易员 2
+	want := []struct {
+		rcpt    string
+		include []string
+		exclude []string
+	}{
+		{"TO:<ops@example.com>", []string{"one (1)", "two (2)"}, nil},
+		{"TO:<trader2@example.com>", []string{"two (2)"}, []string{"one (1)"}},
+	}
+	for _, w := range want {
+		session := waitSession(t, sessions)
+		if session.err != nil {
+			t.Fatalf("server: %v", session.err)
+		}
+		if len(session.rcpt) != 1 || session.rcpt[0] != w.rcpt {
+			t.Fatalf("rcpt = %q, want %s", session.rcpt, w.rcpt)
+		}
+		decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(string(session.data))))
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		for _, s := range w.include {
+			if !strings.Contains(string(decoded), s) {
+				t.Errorf("%s digest missing %q", w.rcpt, s)
+			}
+		}
+		for _, s := range w.exclude {
+			if strings.Contains(string(decoded), s) {
+				t.Errorf("%s digest should not contain %q", w.rcpt, s)
+			}
+		}
+	}
+}
+
+func TestBuildDigestBoundaries(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	to := from.Add(24 * time.Hour)
+	closedAtFrom := from
+	closedAtTo := to
+	closedBefore := from.Add(-time.Second)
+
+	orders := []model.OrderHistory{
+		// 开仓时间等于起点：计入开仓
+		{TraderUserID: "1", TraderName: "trader", OrderID: "open-at-from", ContractSymbol: "AAA/USDT", FirstSeenAt: from},
+		// 开仓时间等于终点：不计入
+		{TraderUserID: "1", TraderName: "trader", OrderID: "open-at-to", ContractSymbol: "BBB/USDT", FirstSeenAt: to},
+		// 之前开仓、起点平仓：只计入平仓
+		{TraderUserID: "1", TraderName: "trader", OrderID: "closed-at-from", ContractSymbol: "CCC/USDT",
+			FirstSeenAt: from.Add(-time.Hour), ClosedAt: &closedAtFrom, RealizedPnl: "10.5"},
+		// 终点平仓：不计入平仓，盈亏不累加
+		{TraderUserID: "1", TraderName: "trader", OrderID: "closed-at-to", ContractSymbol: "DDD/USDT",
+			FirstSeenAt: from.Add(time.Hour), ClosedAt: &closedAtTo, RealizedPnl: "100"},
+		// 起点之前平仓：不计入平仓
+		{TraderUserID: "1", TraderName: "trader", OrderID: "closed-before", ContractSymbol: "EEE/USDT",
+			FirstSeenAt: from.Add(-2 * time.Hour), ClosedAt: &closedBefore, RealizedPnl: "-3"},
+		// 同一时间段内开仓并平仓，盈亏未对账
+		{TraderUserID: "2", TraderName: "other", OrderID: "open-and-close", ContractSymbol: "FFF/USDT",
+			FirstSeenAt: from.Add(time.Hour), ClosedAt: &closedAtFrom, RealizedPnl: ""},
+	}
+
+	subject, text, body := buildDigest(orders, from, to)
+
+	if !strings.Contains(subject, "2024-05-01 00:00 ~ 2024-05-02 00:00") {
+		t.Errorf("subject = %q", subject)
+	}
+
+	// 交易员 1：开仓 AAA、DDD（DDD 在时间段内开仓），平仓 CCC
+	wantSummary := "交易员: trader (1) 开仓 2 单，平仓 1 单，已实现盈亏 " + strconv.FormatFloat(10.5, 'f', 4, 64)
+	if !strings.Contains(text, wantSummary) {
+		t.Errorf("text missing %q:\n%s", wantSummary, text)
+	}
+	if !strings.Contains(text, "交易员: other (2) 开仓 1 单，平仓 1 单，已实现盈亏 0.0000") {
+		t.Errorf("text missing second trader summary:\n%s", text)
+	}
+	for _, symbol := range []string{"AAA/USDT", "CCC/USDT", "DDD/USDT", "FFF/USDT"} {
+		if !strings.Contains(text, symbol) {
+			t.Errorf("text missing %s", symbol)
+		}
+	}
+	for _, symbol := range []string{"BBB/USDT", "EEE/USDT"} {
+		if strings.Contains(text, symbol) {
+			t.Errorf("text should not contain %s", symbol)
+		}
+	}
+
+	// 交易员按首次出现的顺序输出
+	if strings.Index(text, "trader (1)") > strings.Index(text, "other (2)") {
+		t.Error("traders are not in input order")
+	}
+	if strings.Count(body, "<table") != 2 {
+		t.Errorf("html should contain one table per trader:\n%s", body)
+	}
+}
+
+func TestBuildDigestEscapesHTML(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	orders := []model.OrderHistory{
+		{TraderUserID: "1", TraderName: "<script>", ContractSymbol: "A&B", FirstSeenAt: from},
+	}
+
+	_, text, body := buildDigest(orders, from, from.Add(time.Hour))
+	if strings.Contains(body, "<script>") || !strings.Contains(body, "&lt;script&gt;") || !strings.Contains(body, "A&amp;B") {
+		t.Errorf("html not escaped:\n%s", body)
+	}
+	if !strings.Contains(text, "<script>") {
+		t.Errorf("text part should keep raw value:\n%s", text)
+	}
+}
@@ -2,6 +2,9 @@ package notification
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"weex-watchdog/internal/model"
@@ -10,17 +13,24 @@ import (
 // Client 通知服务接口
 type Client interface {
 	BuildNotificationMessage(orders []*model.OrderHistory, isOpen bool) string
+	BuildOrderChangeMessage(changes []*model.OrderChangeEvent) string
 	SendMessage(notification NotificationMessage) error
 }
 
 // Config 通知配置
 type Config struct {
-	Supplier string `mapstructure:"supplier"`
+	Supplier string   `mapstructure:"supplier"` // 单渠道配置，channels 为空时使用
+	Channels []string `mapstructure:"channels"` // 同时启用的通知渠道列表
+	// TemplateDir 通知模板目录，为空时使用各渠道内置格式
+	TemplateDir string `mapstructure:"template_dir"`
+	// Retry 发送失败的通知重试配置
+	Retry RetryConfig `mapstructure:"retry"`
 	// 企业微信配置
 	Wecom struct {
 		CID     string        `mapstructure:"cid"`
 		AgentID string        `mapstructure:"agent_id"`
 		Secret  string        `mapstructure:"secret"`
+		MsgType string        `mapstructure:"msg_type"` // markdown（默认）或 text
 		Timeout time.Duration `mapstructure:"timeout"`
 	} `mapstructure:"wecom"`
 	// WxPusher 配置
@@ -28,22 +38,193 @@ type Config struct {
 		AppToken string `mapstructure:"app_token"`
 		UID      string `mapstructure:"uid"`
 	} `mapstructure:"wxpusher"`
+	// Telegram 配置
+	Telegram struct {
+		BotToken string        `mapstructure:"bot_token"`
+		ChatIDs  []string      `mapstructure:"chat_ids"` // 接收消息的会话ID，可配置多个
+		APIURL   string        `mapstructure:"api_url"`  // Bot API 地址，为空时使用官方地址
+		Timeout  time.Duration `mapstructure:"timeout"`
+	} `mapstructure:"telegram"`
+	// Webhook 配置
+	Webhook struct {
+		URLs    []string      `mapstructure:"urls"`   // 推送地址，可配置多个
+		Secret  string        `mapstructure:"secret"` // HMAC-SHA256 签名密钥，为空时不签名
+		Timeout time.Duration `mapstructure:"timeout"`
+	} `mapstructure:"webhook"`
+	// Discord 配置
+	Discord struct {
+		WebhookURLs []string      `mapstructure:"webhook_urls"`
+		Username    string        `mapstructure:"username"` // 覆盖 webhook 默认显示的名称
+		Timeout     time.Duration `mapstructure:"timeout"`
+	} `mapstructure:"discord"`
+	// Slack 配置
+	Slack struct {
+		WebhookURLs []string      `mapstructure:"webhook_urls"`
+		Timeout     time.Duration `mapstructure:"timeout"`
+	} `mapstructure:"slack"`
+	// 邮件配置
+	Email struct {
+		Host             string              `mapstructure:"host"`
+		Port             int                 `mapstructure:"port"`
+		Username         string              `mapstructure:"username"`
+		Password         string              `mapstructure:"password"`
+		From             string              `mapstructure:"from"`              // 发件地址，为空时使用 username
+		To               []string            `mapstructure:"to"`                // 默认收件人，接收所有交易员的通知
+		TraderRecipients map[string][]string `mapstructure:"trader_recipients"` // 交易员ID -> 额外收件人
+		StartTLS         bool                `mapstructure:"starttls"`          // 要求使用 STARTTLS 加密连接
+		Mode             string              `mapstructure:"mode"`              // immediate / digest / both
+		DigestInterval   string              `mapstructure:"digest_interval"`   // hourly / daily
+		Timeout          time.Duration       `mapstructure:"timeout"`
+	} `mapstructure:"email"`
+}
+
+// RetryConfig 通知重试配置，失败后按 BaseDelay * 2^(n-1) 退避，最长 MaxDelay
+type RetryConfig struct {
+	MaxAttempts int           `mapstructure:"max_attempts"` // 最大发送次数（含首次），达到后标记为 DEAD
+	Interval    time.Duration `mapstructure:"interval"`     // 扫描待重试通知的间隔
+	BaseDelay   time.Duration `mapstructure:"base_delay"`
+	MaxDelay    time.Duration `mapstructure:"max_delay"`
+	BatchSize   int           `mapstructure:"batch_size"` // 每次扫描最多处理的通知数
 }
 
 // NotificationMessage 通知消息结构
 type NotificationMessage struct {
-	Type    string      `json:"type"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data"`
+	Type         string      `json:"type"`
+	Message      string      `json:"message"`
+	Data         interface{} `json:"data"`           // 事件涉及的订单或持仓变化
+	To           string      `json:"to"`             // 接收地址（订阅人），为空时发送到渠道配置的默认接收人
+	TraderUserID string      `json:"trader_user_id"` // 事件所属交易员，测试消息为空
+	Delivered    []string    `json:"delivered"`      // 此前已送达的接收地址和分段，重试时跳过
+}
+
+// targets 消息的接收地址：指定了 To 时只发送到该地址，否则发送到渠道配置的默认地址
+func (m NotificationMessage) targets(defaults []string) []string {
+	if m.To != "" {
+		return []string{m.To}
+	}
+	return defaults
+}
+
+// plainTextFormatter 纯文本需要转换格式的渠道，如使用 HTML 解析模式的 Telegram
+type plainTextFormatter interface {
+	FormatPlainText(text string) string
 }
 
+// FormatPlainText 把纯文本消息（如测试消息）转换为渠道的消息格式
+func FormatPlainText(client Client, text string) string {
+	if formatter, ok := Unwrap(client).(plainTextFormatter); ok {
+		return formatter.FormatPlainText(text)
+	}
+	return text
+}
+
+// Channel 已启用的通知渠道
+type Channel struct {
+	Name   string
+	Client Client
+}
+
+// CreateClient 按 supplier 创建单个通知客户端
 func CreateClient(config *Config) (Client, error) {
-	switch config.Supplier {
+	return createClient(config.Supplier, config)
+}
+
+// CreateChannels 按配置创建所有启用的通知渠道，未配置 channels 时回退到 supplier
+// renderer 不为空时各渠道优先使用模板渲染消息
+func CreateChannels(config *Config, renderer *TemplateRenderer) ([]Channel, error) {
+	names := config.Channels
+	if len(names) == 0 && config.Supplier != "" {
+		names = []string{config.Supplier}
+	}
+	if len(names) == 0 {
+		return nil, errors.New("no notification channel configured")
+	}
+
+	channels := make([]Channel, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		client, err := createClient(name, config)
+		if err != nil {
+			return nil, err
+		}
+		if renderer != nil {
+			client = &templatedClient{Client: client, channel: name, renderer: renderer}
+		}
+		channels = append(channels, Channel{Name: name, Client: client})
+	}
+
+	return channels, nil
+}
+
+// createClient 创建指定渠道的通知客户端
+func createClient(name string, config *Config) (Client, error) {
+	switch name {
 	case "wecom":
 		return NewWecomNotificationClient(config), nil
 	case "wxpusher":
 		return NewWxPusherNotificationClient(config), nil
+	case "telegram":
+		return NewTelegramNotificationClient(config), nil
+	case "webhook":
+		return NewWebhookNotificationClient(config), nil
+	case "discord":
+		return NewDiscordNotificationClient(config), nil
+	case "slack":
+		return NewSlackNotificationClient(config), nil
+	case "email":
+		return NewEmailNotificationClient(config), nil
+	default:
+		return nil, errors.New("unsupported notification supplier: " + name)
+	}
+}
+
+// displayValue 展示用的数值，空值或0显示为"-"
+func displayValue(value string) string {
+	if value == "" || value == "0" {
+		return "-"
+	}
+	return value
+}
+
+// buildCloseDetailHtml 平仓盈亏详情，未对账到历史记录时返回空
+func buildCloseDetailHtml(order *model.OrderHistory) string {
+	if order.RealizedPnl == "" {
+		return ""
+	}
+
+	pnlColor := "#28a745" // 盈利绿色
+	if pnl, err := strconv.ParseFloat(order.RealizedPnl, 64); err == nil && pnl < 0 {
+		pnlColor = "#dc3545" // 亏损红色
+	}
+
+	return fmt.Sprintf(`<div style="display: flex; flex-wrap: wrap; gap: 4px; margin-bottom: 4px;">
+<span style="background-color: #e9ecef; padding: 2px 6px; border-radius: 8px; color: rgb(20,20,20); font-size: 11px;">平仓价: %s</span>
+<span style="background-color: %s; padding: 2px 6px; border-radius: 8px; color: white; font-size: 11px; font-weight: bold;">盈亏: %s</span>
+<span style="background-color: #e9ecef; padding: 2px 6px; border-radius: 8px; color: rgb(20,20,20); font-size: 11px;">手续费: %s</span>
+<span style="background-color: #e9ecef; padding: 2px 6px; border-radius: 8px; color: rgb(20,20,20); font-size: 11px;">方式: %s</span>
+</div>
+`, displayValue(order.AverageClosePrice), pnlColor, order.RealizedPnl, displayValue(order.CloseFee), closedByLabel(order.ClosedBy))
+}
+
+// closedByLabel 平仓方式的中文描述
+func closedByLabel(closedBy string) string {
+	upper := strings.ToUpper(closedBy)
+	switch {
+	case upper == "":
+		return "-"
+	case strings.Contains(upper, "TAKE_PROFIT"):
+		return "止盈"
+	case strings.Contains(upper, "STOP_LOSS"):
+		return "止损"
+	case strings.Contains(upper, "LIQUIDAT"):
+		return "强平"
 	default:
-		return nil, errors.New("unsupported notification supplier: " + config.Supplier)
+		return closedBy
 	}
-}
\ No newline at end of file
+}
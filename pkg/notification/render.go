@@ -0,0 +1,59 @@
+package notification
+
+import (
+	"weex-watchdog/internal/model"
+)
+
+// OrderGroup 同一币种、同一方向的订单
+type OrderGroup struct {
+	Symbol       string
+	PositionSide string
+	Orders       []*model.OrderHistory
+}
+
+// GroupOrders 按币种和方向分组，分组顺序与订单首次出现的顺序一致
+func GroupOrders(orders []*model.OrderHistory) []OrderGroup {
+	var groups []OrderGroup
+	index := make(map[string]int)
+	for _, order := range orders {
+		key := order.ContractSymbol + "_" + order.PositionSide
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, OrderGroup{
+				Symbol:       order.ContractSymbol,
+				PositionSide: order.PositionSide,
+			})
+		}
+		groups[i].Orders = append(groups[i].Orders, order)
+	}
+	return groups
+}
+
+// IsLong 是否做多
+func (g OrderGroup) IsLong() bool {
+	return g.PositionSide == "LONG"
+}
+
+// directionText 持仓方向的中文描述
+func directionText(positionSide string) string {
+	if positionSide == "LONG" {
+		return "做多"
+	}
+	return "做空"
+}
+
+// orderEventTime 开仓通知取首次发现时间，平仓通知取平仓时间
+func orderEventTime(order *model.OrderHistory, isOpen bool) string {
+	switch {
+	case isOpen:
+		return order.FirstSeenAt.Format("15:04:05")
+	case order.CloseTime != nil:
+		return order.CloseTime.Format("15:04:05")
+	case order.ClosedAt != nil:
+		return order.ClosedAt.Format("15:04:05")
+	default:
+		return "未知"
+	}
+}
@@ -0,0 +1,217 @@
+package notification
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"weex-watchdog/internal/model"
+)
+
+// Slack 平台限制
+const (
+	slackMaxBlocks       = 50
+	slackMaxSectionText  = 3000
+	slackMaxHeaderText   = 150
+	slackMaxFallbackText = 4000
+	slackWebhookInterval = time.Second // incoming webhook 每秒1条
+)
+
+// slackText Block Kit 文本对象
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// slackBlock Block Kit 块
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+// slackPayload Slack incoming webhook 请求体
+type slackPayload struct {
+	Text   string       `json:"text"` // 通知栏等不支持 blocks 的场景展示的文本
+	Blocks []slackBlock `json:"blocks,omitempty"`
+}
+
+// SlackNotificationClient Slack incoming webhook 通知服务实现
+// 消息内容为 JSON 序列化的请求体列表，超出块数限制时拆分为多条消息
+type SlackNotificationClient struct {
+	webhookURLs []string
+	client      *http.Client
+	pacer       *pacer
+}
+
+// NewSlackNotificationClient 创建 Slack 通知服务
+func NewSlackNotificationClient(config *Config) *SlackNotificationClient {
+	timeout := config.Slack.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &SlackNotificationClient{
+		webhookURLs: config.Slack.WebhookURLs,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		pacer: newPacer(slackWebhookInterval),
+	}
+}
+
+// BuildNotificationMessage 构建通知消息，每个币种和方向的分组生成一个 section
+func (s *SlackNotificationClient) BuildNotificationMessage(orders []*model.OrderHistory, isOpen bool) string {
+	if len(orders) == 0 {
+		return ""
+	}
+
+	actionText := "新开仓"
+	if !isOpen {
+		actionText = "平仓"
+	}
+	title := fmt.Sprintf("%s %s提醒", getActionIcon(isOpen), actionText)
+	header := slackHeaderBlocks(title, orders[0].TraderName, orders[0].TraderUserID)
+
+	var sections []slackBlock
+	for i, group := range GroupOrders(orders) {
+		if i > 0 {
+			sections = append(sections, slackBlock{Type: "divider"})
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "*%s* %s (%d单)\n", slackEscape(group.Symbol), slackDirection(group.PositionSide), len(group.Orders))
+		for _, order := range group.Orders {
+			fmt.Fprintf(&b, "• 杠杆 %s | 价格 %s | 时间 %s\n",
+				slackEscape(order.OpenLeverage), slackEscape(order.OpenPrice), orderEventTime(order, isOpen))
+			if !isOpen && order.RealizedPnl != "" {
+				fmt.Fprintf(&b, "    平仓价 %s | 盈亏 *%s* | 手续费 %s | 方式 %s\n",
+					slackEscape(displayValue(order.AverageClosePrice)), slackEscape(order.RealizedPnl),
+					slackEscape(displayValue(order.CloseFee)), slackEscape(closedByLabel(order.ClosedBy)))
+			}
+		}
+		sections = append(sections, slackSections(b.String())...)
+	}
+
+	return marshalSlackPayloads(batchSlackBlocks(title+" | 交易员: "+orders[0].TraderName, header, sections))
+}
+
+// BuildOrderChangeMessage 构建持仓变化通知消息
+func (s *SlackNotificationClient) BuildOrderChangeMessage(changes []*model.OrderChangeEvent) string {
+	if len(changes) == 0 {
+		return ""
+	}
+
+	title := "🔄 持仓变化提醒"
+	header := slackHeaderBlocks(title, changes[0].TraderName, changes[0].TraderUserID)
+
+	var b strings.Builder
+	for _, change := range changes {
+		fmt.Fprintf(&b, "*%s* %s *%s*\n• %s → %s | 时间 %s\n",
+			slackEscape(change.ContractSymbol), slackDirection(change.PositionSide), change.ChangeType.Label(),
+			slackEscape(displayValue(change.OldValue)), slackEscape(displayValue(change.NewValue)),
+			change.DetectedAt.Format("15:04:05"))
+	}
+
+	return marshalSlackPayloads(batchSlackBlocks(title+" | 交易员: "+changes[0].TraderName, header, slackSections(b.String())))
+}
+
+// slackHeaderBlocks 标题和交易员信息
+func slackHeaderBlocks(title, traderName, traderUserID string) []slackBlock {
+	return []slackBlock{
+		{Type: "header", Text: &slackText{Type: "plain_text", Text: truncateRunes(title, slackMaxHeaderText)}},
+		{Type: "context", Elements: []slackText{{
+			Type: "mrkdwn",
+			Text: fmt.Sprintf("交易员: *%s* (`%s`)", slackEscape(traderName), slackEscape(traderUserID)),
+		}}},
+	}
+}
+
+// slackSections 将 mrkdwn 文本按 section 长度限制拆分为多个块
+func slackSections(text string) []slackBlock {
+	var blocks []slackBlock
+	for _, chunk := range splitMessage(strings.TrimRight(text, "\n"), slackMaxSectionText) {
+		blocks = append(blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: chunk}})
+	}
+	return blocks
+}
+
+// batchSlackBlocks 按块数限制拆分为多条消息，标题只放在第一条
+func batchSlackBlocks(fallback string, header, blocks []slackBlock) []slackPayload {
+	fallback = truncateRunes(fallback, slackMaxFallbackText)
+	payloads := []slackPayload{{Text: fallback, Blocks: header}}
+	for _, block := range blocks {
+		current := &payloads[len(payloads)-1]
+		if len(current.Blocks) == slackMaxBlocks {
+			payloads = append(payloads, slackPayload{Text: fallback})
+			current = &payloads[len(payloads)-1]
+		}
+		current.Blocks = append(current.Blocks, block)
+	}
+	return payloads
+}
+
+// marshalSlackPayloads 序列化请求体列表
+func marshalSlackPayloads(payloads []slackPayload) string {
+	data, err := json.Marshal(payloads)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// slackDirection 持仓方向的展示文本
+func slackDirection(positionSide string) string {
+	if positionSide == "LONG" {
+		return ":large_green_circle: " + directionText(positionSide)
+	}
+	return ":red_circle: " + directionText(positionSide)
+}
+
+// slackEscape 转义 mrkdwn 中的控制字符
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// SendMessage 将消息发送到所有 webhook，单个 webhook 失败不影响其他 webhook
+func (s *SlackNotificationClient) SendMessage(notification NotificationMessage) error {
+	webhookURLs := notification.targets(s.webhookURLs)
+	if len(webhookURLs) == 0 {
+		return errors.New("slack configuration is incomplete")
+	}
+	if notification.Message == "" {
+		return errors.New("notification message cannot be empty")
+	}
+
+	var payloads []slackPayload
+	if err := json.Unmarshal([]byte(notification.Message), &payloads); err != nil || len(payloads) == 0 {
+		// 纯文本消息（如测试消息）直接作为 text 发送
+		payloads = []slackPayload{{Text: truncateRunes(notification.Message, slackMaxFallbackText)}}
+	}
+
+	// 重试时跳过已送达的地址和分段
+	tracker := newDeliveryTracker(notification)
+	var errs []error
+	for _, url := range webhookURLs {
+		for i, payload := range payloads {
+			body, err := json.Marshal(payload)
+			if err != nil {
+				return tracker.result(fmt.Errorf("failed to marshal slack payload: %w", err))
+			}
+			if err := tracker.send(url, i, func() error { return postChatWebhook(s.client, s.pacer, url, body, slackRetryAfter) }); err != nil {
+				errs = append(errs, fmt.Errorf("slack webhook %s: %w", maskWebhookURL(url), err))
+				break
+			}
+		}
+	}
+
+	return tracker.result(errors.Join(errs...))
+}
+
+// slackRetryAfter 解析 429 响应的 Retry-After 头
+func slackRetryAfter(resp *http.Response, _ []byte) time.Duration {
+	return retryAfterHeader(resp)
+}
@@ -0,0 +1,343 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"weex-watchdog/internal/model"
+)
+
+const (
+	// DefaultTelegramAPIURL 默认 Telegram Bot API 地址
+	DefaultTelegramAPIURL = "https://api.telegram.org"
+	// telegramMaxMessageLength Telegram 单条消息最大字符数
+	telegramMaxMessageLength = 4096
+)
+
+// TelegramNotificationClient Telegram Bot 通知服务实现，消息使用 HTML 格式
+type TelegramNotificationClient struct {
+	apiURL   string
+	botToken string
+	chatIDs  []string
+	client   *http.Client
+}
+
+// NewTelegramNotificationClient 创建 Telegram 通知服务
+func NewTelegramNotificationClient(config *Config) *TelegramNotificationClient {
+	apiURL := strings.TrimRight(config.Telegram.APIURL, "/")
+	if apiURL == "" {
+		apiURL = DefaultTelegramAPIURL
+	}
+
+	timeout := config.Telegram.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &TelegramNotificationClient{
+		apiURL:   apiURL,
+		botToken: config.Telegram.BotToken,
+		chatIDs:  config.Telegram.ChatIDs,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// BuildNotificationMessage 构建通知消息
+// 每行的标签都在行内闭合，按行拆分长消息时不会破坏 HTML 结构
+func (s *TelegramNotificationClient) BuildNotificationMessage(orders []*model.OrderHistory, isOpen bool) string {
+	if len(orders) == 0 {
+		return ""
+	}
+
+	actionText := "新开仓"
+	if !isOpen {
+		actionText = "平仓"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s <b>%s提醒</b>\n", getActionIcon(isOpen), actionText)
+	fmt.Fprintf(&b, "交易员: <b>%s</b> (<code>%s</code>)\n",
+		html.EscapeString(orders[0].TraderName), html.EscapeString(orders[0].TraderUserID))
+
+	for _, group := range GroupOrders(orders) {
+		fmt.Fprintf(&b, "\n<b>%s</b> %s (%d单)\n",
+			html.EscapeString(group.Symbol), telegramDirection(group.PositionSide), len(group.Orders))
+
+		for _, order := range group.Orders {
+			fmt.Fprintf(&b, "• 杠杆 %s | 价格 %s | 时间 %s\n",
+				html.EscapeString(order.OpenLeverage), html.EscapeString(order.OpenPrice), orderEventTime(order, isOpen))
+			if !isOpen && order.RealizedPnl != "" {
+				fmt.Fprintf(&b, "  平仓价 %s | 盈亏 <b>%s</b> | 手续费 %s | 方式 %s\n",
+					html.EscapeString(displayValue(order.AverageClosePrice)), html.EscapeString(order.RealizedPnl),
+					html.EscapeString(displayValue(order.CloseFee)), html.EscapeString(closedByLabel(order.ClosedBy)))
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// BuildOrderChangeMessage 构建持仓变化通知消息
+func (s *TelegramNotificationClient) BuildOrderChangeMessage(changes []*model.OrderChangeEvent) string {
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("🔄 <b>持仓变化提醒</b>\n")
+	fmt.Fprintf(&b, "交易员: <b>%s</b> (<code>%s</code>)\n",
+		html.EscapeString(changes[0].TraderName), html.EscapeString(changes[0].TraderUserID))
+
+	for _, change := range changes {
+		fmt.Fprintf(&b, "\n<b>%s</b> %s <b>%s</b>\n",
+			html.EscapeString(change.ContractSymbol), telegramDirection(change.PositionSide), change.ChangeType.Label())
+		fmt.Fprintf(&b, "• %s → %s | 时间 %s\n",
+			html.EscapeString(displayValue(change.OldValue)), html.EscapeString(displayValue(change.NewValue)),
+			change.DetectedAt.Format("15:04:05"))
+	}
+
+	return b.String()
+}
+
+// telegramDirection 持仓方向的展示文本
+func telegramDirection(positionSide string) string {
+	if positionSide == "LONG" {
+		return "🟢 " + directionText(positionSide)
+	}
+	return "🔴 " + directionText(positionSide)
+}
+
+// FormatPlainText 消息按 HTML 解析，纯文本需要转义
+func (s *TelegramNotificationClient) FormatPlainText(text string) string {
+	return html.EscapeString(text)
+}
+
+// SendMessage 发送消息到所有配置的会话，超长消息拆分为多条发送
+func (s *TelegramNotificationClient) SendMessage(notification NotificationMessage) error {
+	chatIDs := notification.targets(s.chatIDs)
+	if s.botToken == "" || len(chatIDs) == 0 {
+		return errors.New("telegram configuration is incomplete")
+	}
+	if notification.Message == "" {
+		return errors.New("notification message cannot be empty")
+	}
+
+	chunks := splitHTMLMessage(notification.Message, telegramMaxMessageLength)
+
+	// 单个会话发送失败不影响其他会话，重试时跳过已送达的会话和分段
+	tracker := newDeliveryTracker(notification)
+	var errs []error
+	for _, chatID := range chatIDs {
+		for i, chunk := range chunks {
+			if err := tracker.send(chatID, i, func() error { return s.sendChunk(chatID, chunk) }); err != nil {
+				errs = append(errs, fmt.Errorf("chat %s: %w", chatID, err))
+				break
+			}
+		}
+	}
+
+	return tracker.result(errors.Join(errs...))
+}
+
+// sendChunk 调用 sendMessage 接口发送单条消息
+func (s *TelegramNotificationClient) sendChunk(chatID, text string) error {
+	data := map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	}
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal telegram message: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/bot%s/sendMessage", s.apiURL, s.botToken)
+	resp, err := s.client.Post(url, "application/json", bytes.NewReader(jsonData))
+	if err != nil {
+		// 错误信息中的地址包含 bot token，不能原样返回
+		return errors.New("failed to send telegram message: " + strings.ReplaceAll(err.Error(), s.botToken, "***"))
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		ErrorCode   int    `json:"error_code"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode telegram response, status code: %d", resp.StatusCode)
+	}
+	if !result.OK {
+		return fmt.Errorf("telegram API error %d: %s", result.ErrorCode, result.Description)
+	}
+
+	return nil
+}
+
+// splitMessage 按行将消息拆分为不超过 limit 个字符的多段，单行超长时按字符截断
+func splitMessage(message string, limit int) []string {
+	if utf8.RuneCountInString(message) <= limit {
+		return []string{message}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	currentLen := 0
+	flush := func() {
+		if currentLen > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			currentLen = 0
+		}
+	}
+
+	for _, line := range strings.SplitAfter(message, "\n") {
+		lineLen := utf8.RuneCountInString(line)
+		if currentLen+lineLen > limit {
+			flush()
+		}
+		for lineLen > limit {
+			runes := []rune(line)
+			chunks = append(chunks, string(runes[:limit]))
+			line = string(runes[limit:])
+			lineLen -= limit
+		}
+		current.WriteString(line)
+		currentLen += lineLen
+	}
+	flush()
+
+	return chunks
+}
+
+// htmlTag 拆分 HTML 消息时未闭合的标签
+type htmlTag struct {
+	name string
+	raw  string
+}
+
+// splitHTMLMessage 按行拆分 HTML 消息，不会在标签或实体中间截断；
+// 标签跨段时在段尾闭合，并在下一段开头重新打开，每段都是合法的 HTML
+func splitHTMLMessage(message string, limit int) []string {
+	if utf8.RuneCountInString(message) <= limit {
+		return []string{message}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	var open []htmlTag
+	currentLen, prefixLen := 0, 0
+
+	closingLen := func() int {
+		n := 0
+		for _, tag := range open {
+			n += len(tag.name) + 3
+		}
+		return n
+	}
+	flush := func() {
+		if currentLen == prefixLen {
+			return
+		}
+		for i := len(open) - 1; i >= 0; i-- {
+			current.WriteString("</" + open[i].name + ">")
+		}
+		chunks = append(chunks, current.String())
+		current.Reset()
+		currentLen = 0
+		for _, tag := range open {
+			current.WriteString(tag.raw)
+			currentLen += utf8.RuneCountInString(tag.raw)
+		}
+		prefixLen = currentLen
+	}
+
+	for _, line := range strings.SplitAfter(message, "\n") {
+		if currentLen+utf8.RuneCountInString(line)+closingLen() > limit {
+			flush()
+		}
+
+		for _, token := range htmlTokens(line) {
+			need := utf8.RuneCountInString(token)
+			name, closing, isTag := htmlTagName(token)
+			if isTag && !closing {
+				need += len(name) + 3
+			}
+			if currentLen+need+closingLen() > limit {
+				flush()
+			}
+
+			current.WriteString(token)
+			currentLen += utf8.RuneCountInString(token)
+			if !isTag {
+				continue
+			}
+			if !closing {
+				open = append(open, htmlTag{name: name, raw: token})
+				continue
+			}
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i].name == name {
+					open = append(open[:i], open[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+	flush()
+
+	return chunks
+}
+
+// htmlTokens 把一行 HTML 拆成标签、实体和单个字符
+func htmlTokens(line string) []string {
+	var tokens []string
+	for len(line) > 0 {
+		end := 0
+		switch line[0] {
+		case '<':
+			if gt := strings.IndexByte(line, '>'); gt > 0 && !strings.ContainsRune(line[1:gt], '<') {
+				end = gt + 1
+			}
+		case '&':
+			if semi := strings.IndexByte(line, ';'); semi > 0 && semi <= 10 && !strings.ContainsAny(line[1:semi], " <&") {
+				end = semi + 1
+			}
+		}
+		if end <= 0 {
+			_, end = utf8.DecodeRuneInString(line)
+		}
+		tokens = append(tokens, line[:end])
+		line = line[end:]
+	}
+	return tokens
+}
+
+// htmlTagName 解析标签名，token 不是标签时 isTag 为 false
+func htmlTagName(token string) (name string, closing bool, isTag bool) {
+	if len(token) < 3 || token[0] != '<' || token[len(token)-1] != '>' {
+		return "", false, false
+	}
+	inner := token[1 : len(token)-1]
+	if strings.HasPrefix(inner, "/") {
+		closing = true
+		inner = inner[1:]
+	}
+	if end := strings.IndexAny(inner, " \t\n/"); end >= 0 {
+		inner = inner[:end]
+	}
+	if inner == "" {
+		return "", false, false
+	}
+	return strings.ToLower(inner), closing, true
+}
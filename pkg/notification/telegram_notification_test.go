@@ -0,0 +1,295 @@
+package notification
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"weex-watchdog/internal/model"
+)
+
+var (
+	htmlTagPattern    = regexp.MustCompile(`<[^<>]+>`)
+	htmlEntityPattern = regexp.MustCompile(`^&(#[0-9]+|[a-z]+);`)
+)
+
+// checkHTMLChunks 校验拆分结果：每段不超过 limit、标签成对闭合、实体完整，去掉标签后拼接等于原文
+func checkHTMLChunks(t *testing.T, message string, chunks []string, limit int) {
+	t.Helper()
+
+	var text strings.Builder
+	for i, chunk := range chunks {
+		if n := utf8.RuneCountInString(chunk); n > limit {
+			t.Errorf("chunk %d has %d runes, limit %d", i, n, limit)
+		}
+
+		var open []string
+		for _, token := range htmlTokens(chunk) {
+			name, closing, isTag := htmlTagName(token)
+			if !isTag {
+				continue
+			}
+			if !closing {
+				open = append(open, name)
+				continue
+			}
+			if len(open) == 0 || open[len(open)-1] != name {
+				t.Errorf("chunk %d closes unopened tag %s: %q", i, name, chunk)
+				continue
+			}
+			open = open[:len(open)-1]
+		}
+		if len(open) > 0 {
+			t.Errorf("chunk %d leaves tags open %v: %q", i, open, chunk)
+		}
+
+		for j := range chunk {
+			if chunk[j] == '&' && !htmlEntityPattern.MatchString(chunk[j:]) {
+				t.Errorf("chunk %d has a broken entity at %d: %q", i, j, chunk)
+			}
+		}
+
+		text.WriteString(htmlTagPattern.ReplaceAllString(chunk, ""))
+	}
+
+	if want := htmlTagPattern.ReplaceAllString(message, ""); text.String() != want {
+		t.Errorf("text changed after split:\n got %q\nwant %q", text.String(), want)
+	}
+}
+
+func TestSplitHTMLMessageShortMessage(t *testing.T) {
+	message := "<b>平仓提醒</b>\n盈亏 &lt;10&gt;\n"
+	chunks := splitHTMLMessage(message, telegramMaxMessageLength)
+	if len(chunks) != 1 || chunks[0] != message {
+		t.Fatalf("chunks = %q, want the message unchanged", chunks)
+	}
+}
+
+func TestSplitHTMLMessageLongNotification(t *testing.T) {
+	orders := make([]*model.OrderHistory, 0, 80)
+	for i := 0; i < 80; i++ {
+		orders = append(orders, &model.OrderHistory{
+			TraderUserID:      "4200001",
+			TraderName:        "A&B <交易员>",
+			ContractSymbol:    fmt.Sprintf("SYM%dUSDT", i%7),
+			PositionSide:      "LONG",
+			OpenLeverage:      "20",
+			OpenPrice:         "65000.5",
+			AverageClosePrice: "66000.1",
+			RealizedPnl:       "+120.5 & more",
+			CloseFee:          "1.2",
+			ClosedBy:          "TAKE_PROFIT",
+			FirstSeenAt:       time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		})
+	}
+
+	client := NewTelegramNotificationClient(&Config{})
+	message := client.BuildNotificationMessage(orders, false)
+	if utf8.RuneCountInString(message) <= telegramMaxMessageLength {
+		t.Fatalf("test message has %d runes, want more than %d", utf8.RuneCountInString(message), telegramMaxMessageLength)
+	}
+
+	chunks := splitHTMLMessage(message, telegramMaxMessageLength)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want at least 2", len(chunks))
+	}
+	checkHTMLChunks(t, message, chunks, telegramMaxMessageLength)
+}
+
+func TestSplitHTMLMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		limit   int
+		// 每段都应满足的额外检查
+		check func(t *testing.T, i int, chunk string)
+	}{
+		{
+			name:    "tag spanning a split",
+			message: "<b>" + strings.Repeat("line of bold text\n", 10) + "</b>tail\n",
+			limit:   60,
+			check: func(t *testing.T, i int, chunk string) {
+				if i > 0 && !strings.HasPrefix(chunk, "<b>") && !strings.HasPrefix(chunk, "tail") {
+					t.Errorf("chunk %d does not reopen <b>: %q", i, chunk)
+				}
+			},
+		},
+		{
+			name:    "nested tags with attributes",
+			message: `<a href="https://example.com"><b>` + strings.Repeat("链接文本 ", 30) + "</b></a>\n",
+			limit:   80,
+			check: func(t *testing.T, i int, chunk string) {
+				if !strings.HasPrefix(chunk, `<a href="https://example.com"><b>`) {
+					t.Errorf("chunk %d does not reopen nested tags: %q", i, chunk)
+				}
+			},
+		},
+		{
+			name:    "entities at chunk boundary",
+			message: strings.Repeat("x&amp;&lt;&#128200;", 20) + "\n",
+			limit:   23,
+		},
+		{
+			name:    "single line longer than limit",
+			message: strings.Repeat("一二三四五六七八九十", 12),
+			limit:   25,
+		},
+		{
+			name:    "stray angle bracket is text",
+			message: "price < 100 and <b>bold</b> " + strings.Repeat("<x ", 20) + "\n",
+			limit:   20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := splitHTMLMessage(tt.message, tt.limit)
+			if len(chunks) < 2 {
+				t.Fatalf("got %d chunks, want the message split", len(chunks))
+			}
+			checkHTMLChunks(t, tt.message, chunks, tt.limit)
+			if tt.check != nil {
+				for i, chunk := range chunks {
+					tt.check(t, i, chunk)
+				}
+			}
+		})
+	}
+}
+
+// fakeTelegramAPI 假 Bot API，记录每次 sendMessage 请求，failChat 中的会话返回错误
+type fakeTelegramAPI struct {
+	mu       sync.Mutex
+	paths    []string
+	requests []map[string]interface{}
+	failChat map[string]bool
+}
+
+func (f *fakeTelegramAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	f.paths = append(f.paths, r.URL.Path)
+	f.requests = append(f.requests, body)
+	fail := f.failChat[fmt.Sprint(body["chat_id"])]
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if fail {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`))
+		return
+	}
+	w.Write([]byte(`{"ok":true,"result":{}}`))
+}
+
+// sentTo 发送到某个会话的消息文本
+func (f *fakeTelegramAPI) sentTo(chatID string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var texts []string
+	for _, req := range f.requests {
+		if req["chat_id"] == chatID {
+			texts = append(texts, fmt.Sprint(req["text"]))
+		}
+	}
+	return texts
+}
+
+func newTestTelegramClient(apiURL string, chatIDs ...string) *TelegramNotificationClient {
+	config := &Config{}
+	config.Telegram.APIURL = apiURL + "/"
+	config.Telegram.BotToken = "123:token"
+	config.Telegram.ChatIDs = chatIDs
+	config.Telegram.Timeout = 5 * time.Second
+	return NewTelegramNotificationClient(config)
+}
+
+func TestTelegramSendMessage(t *testing.T) {
+	api := &fakeTelegramAPI{}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	client := newTestTelegramClient(server.URL, "100", "-200")
+	message := "<b>开仓提醒</b>\n" + strings.Repeat("• 杠杆 20 | 价格 &lt;65000&gt;\n", 200)
+	if err := client.SendMessage(NotificationMessage{Message: message}); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+
+	for _, path := range api.paths {
+		if path != "/bot123:token/sendMessage" {
+			t.Errorf("request path = %q, want /bot123:token/sendMessage", path)
+		}
+	}
+	for _, req := range api.requests {
+		if req["parse_mode"] != "HTML" {
+			t.Errorf("parse_mode = %v, want HTML", req["parse_mode"])
+		}
+	}
+
+	want := splitHTMLMessage(message, telegramMaxMessageLength)
+	if len(want) < 2 {
+		t.Fatalf("test message produced %d chunks, want at least 2", len(want))
+	}
+	for _, chatID := range []string{"100", "-200"} {
+		got := api.sentTo(chatID)
+		if strings.Join(got, "") != strings.Join(want, "") || len(got) != len(want) {
+			t.Errorf("chat %s received %d chunks, want %d in order", chatID, len(got), len(want))
+		}
+	}
+}
+
+func TestTelegramSendMessagePartialDelivery(t *testing.T) {
+	api := &fakeTelegramAPI{failChat: map[string]bool{"-200": true}}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	client := newTestTelegramClient(server.URL, "100", "-200")
+	notification := NotificationMessage{Message: "<b>平仓提醒</b>\n"}
+
+	err := client.SendMessage(notification)
+	if err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Fatalf("SendMessage error = %v, want API description", err)
+	}
+	if strings.Contains(err.Error(), "123:token") {
+		t.Errorf("error leaks bot token: %v", err)
+	}
+	var partial *DeliveryError
+	if !errors.As(err, &partial) || len(partial.Delivered) != 1 {
+		t.Fatalf("error = %#v, want *DeliveryError with one delivered part", err)
+	}
+
+	// 重试时只发送失败的会话
+	api.mu.Lock()
+	api.failChat = nil
+	api.mu.Unlock()
+	notification.Delivered = partial.Delivered
+	if err := client.SendMessage(notification); err != nil {
+		t.Fatalf("retry SendMessage: %v", err)
+	}
+	if got := api.sentTo("100"); len(got) != 1 {
+		t.Errorf("chat 100 received %d messages, want 1", len(got))
+	}
+	if got := api.sentTo("-200"); len(got) != 2 {
+		t.Errorf("chat -200 received %d messages, want 2 (failed + retried)", len(got))
+	}
+}
+
+func TestTelegramFormatPlainText(t *testing.T) {
+	client := NewTelegramNotificationClient(&Config{})
+	if got := FormatPlainText(client, "a < b & c"); got != "a &lt; b &amp; c" {
+		t.Errorf("FormatPlainText = %q", got)
+	}
+}
@@ -0,0 +1,362 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"weex-watchdog/internal/model"
+	"weex-watchdog/pkg/logger"
+)
+
+// TemplateTrader 模板中的交易员信息
+type TemplateTrader struct {
+	UserID string
+	Name   string
+}
+
+// TemplateData 通知模板可访问的数据
+type TemplateData struct {
+	Event    string // NEW_ORDER / ORDER_CLOSED / ORDER_UPDATED
+	IsOpen   bool   // 是否开仓通知
+	Trader   TemplateTrader
+	Orders   []*model.OrderHistory
+	Groups   []OrderGroup // 按币种和方向分组的订单
+	Changes  []*model.OrderChangeEvent
+	TotalPnl string // 平仓已实现盈亏合计，没有已对账订单时为空
+	Now      time.Time
+}
+
+// NewOrderTemplateData 开仓/平仓通知的模板数据
+func NewOrderTemplateData(orders []*model.OrderHistory, isOpen bool) *TemplateData {
+	data := &TemplateData{
+		Event:  string(model.NotificationTypeNewOrder),
+		IsOpen: isOpen,
+		Orders: orders,
+		Groups: GroupOrders(orders),
+		Now:    time.Now(),
+	}
+	if !isOpen {
+		data.Event = string(model.NotificationTypeOrderClosed)
+	}
+	if len(orders) > 0 {
+		data.Trader = TemplateTrader{UserID: orders[0].TraderUserID, Name: orders[0].TraderName}
+	}
+
+	total, reconciled := 0.0, false
+	for _, order := range orders {
+		if pnl, err := strconv.ParseFloat(order.RealizedPnl, 64); err == nil {
+			total += pnl
+			reconciled = true
+		}
+	}
+	if !isOpen && reconciled {
+		data.TotalPnl = strconv.FormatFloat(total, 'f', -1, 64)
+	}
+
+	return data
+}
+
+// NewChangeTemplateData 持仓变化通知的模板数据
+func NewChangeTemplateData(changes []*model.OrderChangeEvent) *TemplateData {
+	data := &TemplateData{
+		Event:   string(model.NotificationTypeOrderUpdated),
+		Changes: changes,
+		Now:     time.Now(),
+	}
+	if len(changes) > 0 {
+		data.Trader = TemplateTrader{UserID: changes[0].TraderUserID, Name: changes[0].TraderName}
+	}
+	return data
+}
+
+// SampleTemplateData 用于预览模板的示例数据
+func SampleTemplateData(event model.NotificationType) (*TemplateData, error) {
+	now := time.Now()
+	closeTime := now.Add(2 * time.Hour)
+	orders := []*model.OrderHistory{
+		{
+			TraderUserID: "4200000001", TraderName: "示例交易员", OrderID: "700000000000000001",
+			ContractSymbol: "BTC/USDT", PositionSide: "LONG", OpenSize: "0.5", OpenPrice: "65000.5", OpenLeverage: "20",
+			FirstSeenAt: now, LastSeenAt: now,
+		},
+		{
+			TraderUserID: "4200000001", TraderName: "示例交易员", OrderID: "700000000000000002",
+			ContractSymbol: "ETH/USDT", PositionSide: "SHORT", OpenSize: "3", OpenPrice: "3200.25", OpenLeverage: "10",
+			FirstSeenAt: now, LastSeenAt: now,
+		},
+	}
+
+	switch event {
+	case model.NotificationTypeNewOrder:
+		return NewOrderTemplateData(orders, true), nil
+	case model.NotificationTypeOrderClosed:
+		for i, pnl := range []string{"125.8", "-42.3"} {
+			orders[i].Status = model.OrderStatusClosed
+			orders[i].ClosedAt = &closeTime
+			orders[i].CloseTime = &closeTime
+			orders[i].RealizedPnl = pnl
+			orders[i].CloseFee = "1.2"
+			orders[i].ClosedBy = "TAKE_PROFIT"
+		}
+		orders[0].AverageClosePrice = "65500"
+		orders[1].AverageClosePrice = "3214.35"
+		orders[1].ClosedBy = "STOP_LOSS"
+		return NewOrderTemplateData(orders, false), nil
+	case model.NotificationTypeOrderUpdated:
+		return NewChangeTemplateData([]*model.OrderChangeEvent{
+			{
+				TraderUserID: "4200000001", TraderName: "示例交易员", OrderID: "700000000000000001",
+				ContractSymbol: "BTC/USDT", PositionSide: "LONG", ChangeType: model.OrderChangeStopLossMoved,
+				OldValue: "63000", NewValue: "64000", DetectedAt: now,
+			},
+		}), nil
+	default:
+		return nil, fmt.Errorf("unsupported notification event: %s", event)
+	}
+}
+
+// templateFuncs 模板中可用的函数
+var templateFuncs = map[string]interface{}{
+	"direction": directionText,
+	"isLong":    func(positionSide string) bool { return positionSide == "LONG" },
+	"display":   displayValue,
+	"closedBy":  closedByLabel,
+	"eventTime": orderEventTime,
+	"time": func(layout string, t interface{}) string {
+		switch v := t.(type) {
+		case time.Time:
+			return v.Format(layout)
+		case *time.Time:
+			if v != nil {
+				return v.Format(layout)
+			}
+		}
+		return "-"
+	},
+	"baseAsset": func(symbol string) string { return strings.TrimSuffix(symbol, "/USDT") },
+	"isLoss": func(pnl string) bool {
+		value, err := strconv.ParseFloat(pnl, 64)
+		return err == nil && value < 0
+	},
+	"pnlColor": func(pnl string) string {
+		if value, err := strconv.ParseFloat(pnl, 64); err == nil && value < 0 {
+			return "#dc3545"
+		}
+		return "#28a745"
+	},
+	"add": func(a, b int) int { return a + b },
+}
+
+// executor 文本模板和 HTML 模板的公共接口
+type executor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// parseTemplate 解析模板，isHTML 为 true 时使用 html/template 自动转义
+func parseTemplate(name, text string, isHTML bool) (executor, error) {
+	if isHTML {
+		return htmltemplate.New(name).Funcs(templateFuncs).Parse(text)
+	}
+	return texttemplate.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+// RenderTemplate 渲染一段模板文本，用于预览未保存的模板
+func RenderTemplate(text string, isHTML bool, data *TemplateData) (string, error) {
+	tmpl, err := parseTemplate("preview", text, isHTML)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// cachedTemplate 已解析的模板文件
+type cachedTemplate struct {
+	modTime time.Time
+	tmpl    executor
+}
+
+// TemplateRenderer 从模板目录渲染通知内容
+// 模板路径为 {dir}/{渠道}/{事件}.html 或 .tmpl，wxpusher、wecom、email 渠道目录下没有时使用 {dir}/default/ 下的同名模板；
+// .html 使用 html/template，.tmpl 使用 text/template。每次渲染前检查文件修改时间，修改后自动重新加载
+type TemplateRenderer struct {
+	dir    string
+	mu     sync.Mutex
+	cache  map[string]*cachedTemplate
+	logger *logger.Logger
+}
+
+// NewTemplateRenderer 创建模板渲染器，dir 为空时返回 nil
+func NewTemplateRenderer(dir string, logger *logger.Logger) *TemplateRenderer {
+	if dir == "" {
+		return nil
+	}
+	return &TemplateRenderer{
+		dir:    dir,
+		cache:  make(map[string]*cachedTemplate),
+		logger: logger,
+	}
+}
+
+// Render 渲染渠道和事件对应的模板，没有对应模板文件时 found 为 false
+func (r *TemplateRenderer) Render(channel string, event model.NotificationType, data *TemplateData) (content string, found bool, err error) {
+	content, _, found, err = r.render(channel, event, data)
+	return content, found, err
+}
+
+// render 渲染模板并返回使用的模板路径
+func (r *TemplateRenderer) render(channel string, event model.NotificationType, data *TemplateData) (content, path string, found bool, err error) {
+	if r == nil {
+		return "", "", false, nil
+	}
+
+	path, isHTML, ok := r.lookup(channel, event)
+	if !ok {
+		return "", "", false, nil
+	}
+
+	tmpl, err := r.load(path, isHTML)
+	if err != nil {
+		return "", path, true, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", path, true, fmt.Errorf("failed to render template %s: %w", path, err)
+	}
+	return buf.String(), path, true, nil
+}
+
+// defaultTemplateChannels 可以使用 default/ 模板的渠道
+// Telegram、Discord、Slack 和 Webhook 的消息有固定格式或结构，只使用各自目录下的模板
+var defaultTemplateChannels = map[string]bool{
+	"wxpusher": true,
+	"wecom":    true,
+	"email":    true,
+}
+
+// lookup 查找模板文件
+func (r *TemplateRenderer) lookup(channel string, event model.NotificationType) (path string, isHTML bool, ok bool) {
+	name := strings.ToLower(string(event))
+	dirs := []string{channel}
+	if defaultTemplateChannels[channel] {
+		dirs = append(dirs, "default")
+	}
+	for _, dir := range dirs {
+		for _, ext := range []string{".html", ".tmpl"} {
+			path := filepath.Join(r.dir, dir, name+ext)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, ext == ".html", true
+			}
+		}
+	}
+	return "", false, false
+}
+
+// load 读取并解析模板，文件未修改时使用缓存
+func (r *TemplateRenderer) load(path string, isHTML bool) (executor, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat template %s: %w", path, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cached, ok := r.cache[path]; ok && cached.modTime.Equal(info.ModTime()) {
+		return cached.tmpl, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template %s: %w", path, err)
+	}
+	tmpl, err := parseTemplate(filepath.Base(path), string(content), isHTML)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", path, err)
+	}
+
+	r.cache[path] = &cachedTemplate{modTime: info.ModTime(), tmpl: tmpl}
+	return tmpl, nil
+}
+
+// templatedClient 优先使用模板渲染消息，没有模板或渲染失败时回退到渠道内置格式
+type templatedClient struct {
+	Client
+	channel  string
+	renderer *TemplateRenderer
+}
+
+// immediateSwitch 可以关闭逐条事件消息的渠道，如仅发送汇总的邮件
+type immediateSwitch interface {
+	ImmediateEnabled() bool
+}
+
+// BuildNotificationMessage 构建通知消息
+func (c *templatedClient) BuildNotificationMessage(orders []*model.OrderHistory, isOpen bool) string {
+	if len(orders) == 0 || !c.immediateEnabled() {
+		return ""
+	}
+	data := NewOrderTemplateData(orders, isOpen)
+	if content, ok := c.render(model.NotificationType(data.Event), data); ok {
+		return content
+	}
+	return c.Client.BuildNotificationMessage(orders, isOpen)
+}
+
+// BuildOrderChangeMessage 构建持仓变化通知消息
+func (c *templatedClient) BuildOrderChangeMessage(changes []*model.OrderChangeEvent) string {
+	if len(changes) == 0 || !c.immediateEnabled() {
+		return ""
+	}
+	data := NewChangeTemplateData(changes)
+	if content, ok := c.render(model.NotificationTypeOrderUpdated, data); ok {
+		return content
+	}
+	return c.Client.BuildOrderChangeMessage(changes)
+}
+
+// immediateEnabled 原始客户端是否发送逐条事件消息
+func (c *templatedClient) immediateEnabled() bool {
+	if client, ok := c.Client.(immediateSwitch); ok {
+		return client.ImmediateEnabled()
+	}
+	return true
+}
+
+// render 渲染模板，渲染失败时记录日志并回退到内置格式
+func (c *templatedClient) render(event model.NotificationType, data *TemplateData) (string, bool) {
+	content, path, found, err := c.renderer.render(c.channel, event, data)
+	if err != nil {
+		if c.renderer.logger != nil {
+			c.renderer.logger.WithFields(map[string]interface{}{
+				"channel":  c.channel,
+				"event":    event,
+				"template": path,
+				"error":    err,
+			}).Error("Failed to render notification template, falling back to built-in format")
+		}
+		return "", false
+	}
+	return content, found
+}
+
+// Unwrap 返回渠道的原始客户端
+func Unwrap(client Client) Client {
+	if templated, ok := client.(*templatedClient); ok {
+		return templated.Client
+	}
+	return client
+}
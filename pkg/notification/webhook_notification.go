@@ -0,0 +1,200 @@
+package notification
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"weex-watchdog/internal/model"
+)
+
+// Webhook 请求头
+const (
+	WebhookEventHeader          = "X-Watchdog-Event"
+	WebhookTimestampHeader      = "X-Watchdog-Timestamp"
+	WebhookSignatureHeader      = "X-Watchdog-Signature"
+	WebhookIdempotencyKeyHeader = "Idempotency-Key"
+)
+
+// WebhookEvent 推送给下游的结构化事件
+type WebhookEvent struct {
+	ID           string                    `json:"id"`    // 幂等键，同一事件重复推送时保持不变
+	Event        string                    `json:"event"` // NEW_ORDER / ORDER_CLOSED / ORDER_UPDATED
+	TraderUserID string                    `json:"trader_user_id"`
+	TraderName   string                    `json:"trader_name"`
+	OccurredAt   time.Time                 `json:"occurred_at"`
+	Orders       []*model.OrderHistory     `json:"orders,omitempty"`
+	Changes      []*model.OrderChangeEvent `json:"changes,omitempty"`
+}
+
+// WebhookNotificationClient 通用 Webhook 通知服务实现，以签名的 JSON 事件推送到配置的地址
+type WebhookNotificationClient struct {
+	urls   []string
+	secret string
+	client *http.Client
+}
+
+// NewWebhookNotificationClient 创建 Webhook 通知服务
+func NewWebhookNotificationClient(config *Config) *WebhookNotificationClient {
+	timeout := config.Webhook.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &WebhookNotificationClient{
+		urls:   config.Webhook.URLs,
+		secret: config.Webhook.Secret,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// BuildNotificationMessage 构建开仓/平仓事件，消息内容即推送的 JSON
+func (s *WebhookNotificationClient) BuildNotificationMessage(orders []*model.OrderHistory, isOpen bool) string {
+	if len(orders) == 0 {
+		return ""
+	}
+
+	eventType := model.NotificationTypeNewOrder
+	if !isOpen {
+		eventType = model.NotificationTypeOrderClosed
+	}
+
+	keyParts := make([]string, 0, len(orders))
+	for _, order := range orders {
+		keyParts = append(keyParts, order.OrderID)
+	}
+
+	return marshalWebhookEvent(&WebhookEvent{
+		ID:           webhookEventID(eventType, orders[0].TraderUserID, keyParts),
+		Event:        string(eventType),
+		TraderUserID: orders[0].TraderUserID,
+		TraderName:   orders[0].TraderName,
+		OccurredAt:   time.Now(),
+		Orders:       orders,
+	})
+}
+
+// BuildOrderChangeMessage 构建持仓变化事件
+func (s *WebhookNotificationClient) BuildOrderChangeMessage(changes []*model.OrderChangeEvent) string {
+	if len(changes) == 0 {
+		return ""
+	}
+
+	keyParts := make([]string, 0, len(changes))
+	for _, change := range changes {
+		keyParts = append(keyParts, fmt.Sprintf("%s:%s:%s:%d",
+			change.OrderID, change.ChangeType, change.NewValue, change.DetectedAt.UnixMilli()))
+	}
+
+	return marshalWebhookEvent(&WebhookEvent{
+		ID:           webhookEventID(model.NotificationTypeOrderUpdated, changes[0].TraderUserID, keyParts),
+		Event:        string(model.NotificationTypeOrderUpdated),
+		TraderUserID: changes[0].TraderUserID,
+		TraderName:   changes[0].TraderName,
+		OccurredAt:   time.Now(),
+		Changes:      changes,
+	})
+}
+
+// webhookEventID 由事件类型、交易员和订单生成幂等键
+func webhookEventID(eventType model.NotificationType, traderUserID string, keyParts []string) string {
+	sum := sha256.Sum256([]byte(string(eventType) + "|" + traderUserID + "|" + strings.Join(keyParts, ",")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// marshalWebhookEvent 序列化事件，失败时返回空字符串（分发器会跳过空消息）
+func marshalWebhookEvent(event *WebhookEvent) string {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// SignWebhookPayload 计算签名：HMAC-SHA256(secret, timestamp + "." + body)，下游按相同方式校验
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SendMessage 推送事件到所有配置的地址，单个地址失败不影响其他地址
+func (s *WebhookNotificationClient) SendMessage(notification NotificationMessage) error {
+	urls := notification.targets(s.urls)
+	if len(urls) == 0 {
+		return errors.New("webhook configuration is incomplete")
+	}
+
+	body := []byte(notification.Message)
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil || event.ID == "" {
+		// 非事件消息（如测试消息）包装为事件后发送
+		now := time.Now()
+		event = WebhookEvent{
+			ID:         webhookEventID(model.NotificationType(notification.Type), "", []string{strconv.FormatInt(now.UnixNano(), 10)}),
+			Event:      notification.Type,
+			OccurredAt: now,
+		}
+		payload := struct {
+			WebhookEvent
+			Message string `json:"message"`
+		}{event, notification.Message}
+		if body, err = json.Marshal(payload); err != nil {
+			return fmt.Errorf("failed to marshal webhook payload: %w", err)
+		}
+	}
+
+	// 重试时跳过已送达的地址
+	tracker := newDeliveryTracker(notification)
+	var errs []error
+	for _, url := range urls {
+		if err := tracker.send(url, 0, func() error { return s.post(url, event.ID, event.Event, body) }); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", url, err))
+		}
+	}
+
+	return tracker.result(errors.Join(errs...))
+}
+
+// post 发送单次请求，每次请求使用新的时间戳和签名
+func (s *WebhookNotificationClient) post(url, eventID, eventType string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, eventType)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookIdempotencyKeyHeader, eventID)
+	if s.secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(s.secret, timestamp, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("webhook failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
@@ -6,24 +6,72 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"weex-watchdog/internal/model"
 )
 
+// wecomMaxMessageRunes 企业微信消息内容最长 2048 字节，按每个字符最多 4 字节拆分
+const wecomMaxMessageRunes = 512
+
+// wecomAPIURL 企业微信接口地址
+const wecomAPIURL = "https://qyapi.weixin.qq.com/cgi-bin"
+
+// wecomTokenRefreshMargin access_token 在过期前提前刷新的时间
+const wecomTokenRefreshMargin = 5 * time.Minute
+
+// 企业微信 access_token 失效的错误码
+const (
+	wecomErrInvalidToken = 40014
+	wecomErrTokenExpired = 42001
+)
+
+// wecomAPIError 企业微信接口返回的错误
+type wecomAPIError struct {
+	Code int
+	Msg  string
+}
+
+func (e *wecomAPIError) Error() string {
+	return fmt.Sprintf("WeCom API error %d: %s", e.Code, e.Msg)
+}
+
+// isWecomTokenError 错误是否由 access_token 无效或过期引起
+func isWecomTokenError(err error) bool {
+	var apiErr *wecomAPIError
+	return errors.As(err, &apiErr) && (apiErr.Code == wecomErrInvalidToken || apiErr.Code == wecomErrTokenExpired)
+}
+
 // WecomNotificationClient HTTP通知服务实现
 type WecomNotificationClient struct {
 	wecomCID     string
 	wecomAgentID string
 	wecomSecret  string
+	msgType      string
+	apiURL       string
 	client       *http.Client
+
+	tokenMu        sync.Mutex
+	token          string    // 缓存的 access_token
+	tokenExpiresAt time.Time // 缓存失效时间，已扣除提前刷新的时间
 }
 
 // NewWecomNotificationClient 创建HTTP通知服务
 func NewWecomNotificationClient(config *Config) *WecomNotificationClient {
+	msgType := config.Wecom.MsgType
+	if msgType != "text" {
+		msgType = "markdown"
+	}
+
 	return &WecomNotificationClient{
 		wecomCID:     config.Wecom.CID,
 		wecomAgentID: config.Wecom.AgentID,
 		wecomSecret:  config.Wecom.Secret,
+		msgType:      msgType,
+		apiURL:       wecomAPIURL,
 		client: &http.Client{
 			Timeout: config.Wecom.Timeout,
 		},
@@ -31,118 +79,65 @@ func NewWecomNotificationClient(config *Config) *WecomNotificationClient {
 }
 
 // BuildNotificationMessage 构建通知消息
+// 企业微信应用消息不支持 HTML，内置格式使用 markdown（msg_type 为 text 时发送纯文本）
 func (s *WecomNotificationClient) BuildNotificationMessage(orders []*model.OrderHistory, isOpen bool) string {
 	if len(orders) == 0 {
 		return ""
 	}
 
-	const maxLength = 40000
-	var result string
-
-	// 获取交易员ID（所有订单的交易员都相同）
-	traderUserID := orders[0].TraderUserID
-
-	// 设置统一的大标题
-	var titleColor, actionText string
-	if isOpen {
-		titleColor = "#007bff"
-		actionText = "新开仓"
-	} else {
-		titleColor = "#6c757d"
+	actionText := "新开仓"
+	if !isOpen {
 		actionText = "平仓"
 	}
 
-	headerHtml := fmt.Sprintf(`<div style="border: 2px solid %s; border-radius: 8px; padding: 8px; background-color: #f8f9fa; margin: 5px 0;">
-<h3 style="color: %s; margin: 0 0 6px 0; padding: 0;">%s %s提醒</h3>
-<div style="color: #333; font-weight: bold; margin-bottom: 8px;">交易员: %s</div>
-`, titleColor, titleColor, getWecomActionIcon(isOpen), actionText, traderUserID)
-
-	result = headerHtml
-	if len(result) >= maxLength {
-		return result[:maxLength]
-	}
-
-	// 按币种和方向分组
-	groupedOrders := make(map[string][]*model.OrderHistory)
-	for _, order := range orders {
-		key := order.ContractSymbol + "_" + order.PositionSide
-		groupedOrders[key] = append(groupedOrders[key], order)
-	}
-
-	// 为每个分组生成消息体
-	for _, groupOrders := range groupedOrders {
-		if len(groupOrders) == 0 {
-			continue
-		}
-
-		symbol := groupOrders[0].ContractSymbol
-		positionSide := groupOrders[0].PositionSide
-
-		// 确定方向和颜色
-		var directionText, directionColor string
-		if positionSide == "LONG" {
-			directionText = "做多"
-			directionColor = "#28a745" // 绿色
-		} else {
-			directionText = "做空"
-			directionColor = "#dc3545" // 红色
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s %s提醒\n", getWecomActionIcon(isOpen), actionText)
+	fmt.Fprintf(&b, "> 交易员: **%s** (%s)\n", orders[0].TraderName, orders[0].TraderUserID)
+
+	for _, group := range GroupOrders(orders) {
+		fmt.Fprintf(&b, "\n**%s** %s (%d单)\n", group.Symbol, wecomDirection(group.PositionSide), len(group.Orders))
+
+		for _, order := range group.Orders {
+			fmt.Fprintf(&b, "> 杠杆: %s | 价格: %s | 时间: %s\n", order.OpenLeverage, order.OpenPrice, orderEventTime(order, isOpen))
+			if !isOpen && order.RealizedPnl != "" {
+				pnlColor := "info"
+				if pnl, err := strconv.ParseFloat(order.RealizedPnl, 64); err == nil && pnl < 0 {
+					pnlColor = "warning"
+				}
+				fmt.Fprintf(&b, "> 平仓价: %s | 盈亏: <font color=\"%s\">%s</font> | 手续费: %s | 方式: %s\n",
+					displayValue(order.AverageClosePrice), pnlColor, order.RealizedPnl, displayValue(order.CloseFee), closedByLabel(order.ClosedBy))
+			}
 		}
+	}
 
-		// 构建这个组的消息体
-		groupHtml := fmt.Sprintf(`<div style="border: 1px solid #dee2e6; border-radius: 6px; padding: 10px; margin: 6px 0; background-color: #ffffff;">
-<div style="display: flex; align-items: center; margin-bottom: 8px;">
-<span style="color: #f39c12; font-weight: bold; font-size: 14px; margin-right: 8px;">%s</span>
-<span style="background-color: %s; color: white; padding: 2px 8px; border-radius: 12px; font-size: 12px; font-weight: bold;">%s</span>
-<span style="margin-left: 8px; color: #666; font-size: 12px;">(%d单)</span>
-</div>
-`, symbol, directionColor, directionText, len(groupOrders))
-
-		// 检查长度
-		if len(result)+len(groupHtml) >= maxLength {
-			break
-		}
-		result += groupHtml
-
-		// 添加订单详情
-		for _, order := range groupOrders {
-			var timeStr string
-			if isOpen {
-				timeStr = order.FirstSeenAt.Format("15:04:05")
-			} else if order.ClosedAt != nil {
-				timeStr = order.ClosedAt.Format("15:04:05")
-			} else {
-				timeStr = "未知"
-			}
+	return b.String()
+}
 
-			orderDetailHtml := fmt.Sprintf(`<div style="display: flex; flex-wrap: wrap; gap: 4px; margin-bottom: 4px;">
-<span style="background-color: #e9ecef; padding: 2px 6px; border-radius: 8px; font-size: 11px;">杠杆: %s</span>
-<span style="background-color: #e9ecef; padding: 2px 6px; border-radius: 8px; font-size: 11px;">价格: %s</span>
-<span style="background-color: #e9ecef; padding: 2px 6px; border-radius: 8px; font-size: 11px;">时间: %s</span>
-</div>
-`, order.OpenLeverage, order.OpenPrice, timeStr)
+// BuildOrderChangeMessage 构建持仓变化通知消息
+func (s *WecomNotificationClient) BuildOrderChangeMessage(changes []*model.OrderChangeEvent) string {
+	if len(changes) == 0 {
+		return ""
+	}
 
-			// 检查长度
-			if len(result)+len(orderDetailHtml) >= maxLength {
-				break
-			}
-			result += orderDetailHtml
-		}
+	var b strings.Builder
+	b.WriteString("### 🔄 持仓变化提醒\n")
+	fmt.Fprintf(&b, "> 交易员: **%s** (%s)\n", changes[0].TraderName, changes[0].TraderUserID)
 
-		// 关闭这个组的div
-		closingDiv := "</div>"
-		if len(result)+len(closingDiv) >= maxLength {
-			break
-		}
-		result += closingDiv
+	for _, change := range changes {
+		fmt.Fprintf(&b, "\n**%s** %s **%s**\n", change.ContractSymbol, wecomDirection(change.PositionSide), change.ChangeType.Label())
+		fmt.Fprintf(&b, "> %s → %s | 时间: %s\n",
+			displayValue(change.OldValue), displayValue(change.NewValue), change.DetectedAt.Format("15:04:05"))
 	}
 
-	// 关闭整个消息的div
-	closingDiv := "</div>"
-	if len(result)+len(closingDiv) < maxLength {
-		result += closingDiv
-	}
+	return b.String()
+}
 
-	return result
+// wecomDirection 持仓方向，企业微信 markdown 只支持 info(绿)/comment(灰)/warning(橙红) 三种颜色
+func wecomDirection(positionSide string) string {
+	if positionSide == "LONG" {
+		return `<font color="info">` + directionText(positionSide) + "</font>"
+	}
+	return `<font color="warning">` + directionText(positionSide) + "</font>"
 }
 
 // getWecomActionIcon 获取操作图标
@@ -159,18 +154,48 @@ func (s *WecomNotificationClient) SendMessage(notification NotificationMessage)
 		return errors.New("wecom configuration is incomplete")
 	}
 
+	// 订阅人地址为企业微信成员账号，多个账号用 | 分隔
+	toUser := notification.targets([]string{"@all"})[0]
+
+	// 超长消息按行拆分为多条发送，重试时跳过已送达的分段
+	tracker := newDeliveryTracker(notification)
+	for i, chunk := range splitMessage(notification.Message, wecomMaxMessageRunes) {
+		if err := tracker.send(toUser, i, func() error { return s.sendWithToken(toUser, chunk) }); err != nil {
+			return tracker.result(err)
+		}
+	}
+
+	return nil
+}
+
+// sendWithToken 使用缓存的 access_token 发送，token 失效时刷新后重试一次
+func (s *WecomNotificationClient) sendWithToken(toUser, content string) error {
 	token, err := s.getToken()
-	if err != nil || token == "" {
-		return fmt.Errorf("failed to get WeCom token: %w", err)
+	if err != nil {
+		return err
+	}
+
+	err = s.send(token, toUser, content)
+	if !isWecomTokenError(err) {
+		return err
 	}
 
-	url := fmt.Sprintf("https://qyapi.weixin.qq.com/cgi-bin/message/send?access_token=%s", token)
+	s.invalidateToken(token)
+	if token, err = s.getToken(); err != nil {
+		return err
+	}
+	return s.send(token, toUser, content)
+}
+
+// send 发送单条应用消息
+func (s *WecomNotificationClient) send(token, toUser, content string) error {
+	url := fmt.Sprintf("%s/message/send?access_token=%s", s.apiURL, token)
 	data := map[string]interface{}{
-		"touser":  "@all",
+		"touser":  toUser,
 		"agentid": s.wecomAgentID,
-		"msgtype": "text",
-		"text": map[string]string{
-			"content": notification.Message,
+		"msgtype": s.msgType,
+		s.msgType: map[string]string{
+			"content": content,
 		},
 		"duplicate_check_interval": 600,
 	}
@@ -180,36 +205,53 @@ func (s *WecomNotificationClient) SendMessage(notification NotificationMessage)
 	}
 
 	resp, err := s.client.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	fmt.Println("Sending notification to WeCom:", string(jsonData))
 	if err != nil {
-		return fmt.Errorf("failed to send notification: %w", err)
+		// 错误信息中的地址包含 access_token，不能原样返回
+		return errors.New("failed to send notification: " + strings.ReplaceAll(err.Error(), token, "***"))
 	}
 	defer resp.Body.Close()
 
-	// 读取并打印响应内容
-	var respBody bytes.Buffer
-	_, err = respBody.ReadFrom(resp.Body)
-	if err != nil {
-		fmt.Printf("Failed to read response body: %v\n", err)
-	} else {
-		fmt.Println("WeCom response:", respBody.String())
-	}
-
 	if resp.StatusCode >= 400 {
 		return fmt.Errorf("notification failed with status: %d", resp.StatusCode)
 	}
 
+	// HTTP 200 时仍需检查响应中的 errcode
+	var result struct {
+		ErrCode     int    `json:"errcode"`
+		ErrMsg      string `json:"errmsg"`
+		InvalidUser string `json:"invaliduser"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode WeCom response: %w", err)
+	}
+	if result.ErrCode != 0 {
+		return &wecomAPIError{Code: result.ErrCode, Msg: result.ErrMsg}
+	}
+	if result.InvalidUser != "" && toUser != "@all" {
+		return fmt.Errorf("WeCom rejected recipients: %s", result.InvalidUser)
+	}
+
 	return nil
 }
 
+// getToken 获取 access_token，缓存有效时直接返回，过期前 wecomTokenRefreshMargin 重新获取
 func (s *WecomNotificationClient) getToken() (string, error) {
 	if s.wecomCID == "" || s.wecomSecret == "" {
 		return "", errors.New("wecom configuration is incomplete")
 	}
-	url := fmt.Sprintf("https://qyapi.weixin.qq.com/cgi-bin/gettoken?corpid=%s&corpsecret=%s", s.wecomCID, s.wecomSecret)
+
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+
+	if s.token != "" && time.Now().Before(s.tokenExpiresAt) {
+		return s.token, nil
+	}
+
+	url := fmt.Sprintf("%s/gettoken?corpid=%s&corpsecret=%s", s.apiURL, s.wecomCID, s.wecomSecret)
 	resp, err := s.client.Get(url)
 	if err != nil {
-		return "", fmt.Errorf("failed to get WeCom token: %w", err)
+		// 错误信息中的地址包含 secret，不能原样返回
+		return "", errors.New("failed to get WeCom token: " + strings.ReplaceAll(err.Error(), s.wecomSecret, "***"))
 	}
 	defer resp.Body.Close()
 
@@ -218,17 +260,41 @@ func (s *WecomNotificationClient) getToken() (string, error) {
 	}
 
 	var result struct {
-		ErrCode int    `json:"errcode"`
-		ErrMsg  string `json:"errmsg"`
-		Token   string `json:"access_token"`
+		ErrCode   int    `json:"errcode"`
+		ErrMsg    string `json:"errmsg"`
+		Token     string `json:"access_token"`
+		ExpiresIn int    `json:"expires_in"` // 有效期（秒），通常为 7200
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return "", fmt.Errorf("failed to decode WeCom token response: %w", err)
 	}
 
 	if result.ErrCode != 0 {
-		return "", fmt.Errorf("WeCom API error: %s", result.ErrMsg)
+		return "", &wecomAPIError{Code: result.ErrCode, Msg: result.ErrMsg}
+	}
+	if result.Token == "" {
+		return "", errors.New("WeCom returned an empty access token")
+	}
+
+	ttl := time.Duration(result.ExpiresIn) * time.Second
+	if ttl > 2*wecomTokenRefreshMargin {
+		ttl -= wecomTokenRefreshMargin
+	} else {
+		ttl /= 2
 	}
+	s.token = result.Token
+	s.tokenExpiresAt = time.Now().Add(ttl)
 
-	return result.Token, nil
+	return s.token, nil
+}
+
+// invalidateToken 丢弃失效的 access_token，其他请求已刷新过时保留新的 token
+func (s *WecomNotificationClient) invalidateToken(token string) {
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+
+	if s.token == token {
+		s.token = ""
+		s.tokenExpiresAt = time.Time{}
+	}
 }
@@ -39,37 +39,23 @@ func (s *WxPusherNotificationClient) BuildNotificationMessage(orders []*model.Or
 	// 获取交易员
 	traderName := orders[0].TraderName
 
-	// 按币种和方向分组
-	groupedOrders := make(map[string][]*model.OrderHistory)
-	for _, order := range orders {
-		key := order.ContractSymbol + "_" + order.PositionSide
-		groupedOrders[key] = append(groupedOrders[key], order)
-	}
+	groups := GroupOrders(orders)
 
 	// 生成开单预览
-	previewParts := make([]string, 0, len(groupedOrders))
-	for _, groupOrders := range groupedOrders {
-		if len(groupOrders) == 0 {
-			continue
-		}
-		
-		symbol := strings.Replace(groupOrders[0].ContractSymbol, "/USDT", "", -1)
-		positionSide := groupOrders[0].PositionSide
-		
-		var directionText, directionColor string
-		if positionSide == "LONG" {
-			directionText = "多"
-			directionColor = "#28a745" // 绿色
-		} else {
-			directionText = "空"
-			directionColor = "#dc3545" // 红色
+	previewParts := make([]string, 0, len(groups))
+	for _, group := range groups {
+		symbol := strings.Replace(group.Symbol, "/USDT", "", -1)
+
+		shortText, directionColor := "空", "#dc3545" // 红色
+		if group.IsLong() {
+			shortText, directionColor = "多", "#28a745" // 绿色
 		}
-		
-		previewPart := fmt.Sprintf(`<span style="display: inline-block; margin: 2px 4px; padding: 2px 6px; background-color: %s; color: white; border-radius: 10px; font-size: 11px; font-weight: bold;">%s(%s)</span>`, 
-			directionColor, symbol, directionText)
+
+		previewPart := fmt.Sprintf(`<span style="display: inline-block; margin: 2px 4px; padding: 2px 6px; background-color: %s; color: white; border-radius: 10px; font-size: 11px; font-weight: bold;">%s(%s)</span>`,
+			directionColor, symbol, shortText)
 		previewParts = append(previewParts, previewPart)
 	}
-	
+
 	previewHtml := ""
 	if len(previewParts) > 0 {
 		previewHtml = fmt.Sprintf(`<div style="margin-top: 6px; margin-bottom: 8px;">%s</div>`, 
@@ -99,22 +85,11 @@ func (s *WxPusherNotificationClient) BuildNotificationMessage(orders []*model.Or
 
 
 	// 为每个分组生成消息体
-	for _, groupOrders := range groupedOrders {
-		if len(groupOrders) == 0 {
-			continue
-		}
-
-		symbol := groupOrders[0].ContractSymbol
-		positionSide := groupOrders[0].PositionSide
-
-		// 确定方向和颜色
-		var directionText, directionColor string
-		if positionSide == "LONG" {
-			directionText = "做多"
+	for _, group := range groups {
+		// 确定方向颜色
+		directionColor := "#dc3545" // 红色
+		if group.IsLong() {
 			directionColor = "#28a745" // 绿色
-		} else {
-			directionText = "做空"
-			directionColor = "#dc3545" // 红色
 		}
 
 		// 构建这个组的消息体
@@ -124,7 +99,7 @@ func (s *WxPusherNotificationClient) BuildNotificationMessage(orders []*model.Or
 <span style="background-color: %s; color: white; padding: 2px 8px; border-radius: 12px; font-size: 12px; font-weight: bold;">%s</span>
 <span style="margin-left: 8px; color: #666; font-size: 12px;">(%d单)</span>
 </div>
-`, symbol, directionColor, directionText, len(groupOrders))
+`, group.Symbol, directionColor, directionText(group.PositionSide), len(group.Orders))
 
 		// 检查长度
 		if len(result)+len(groupHtml) >= maxLength {
@@ -133,7 +108,7 @@ func (s *WxPusherNotificationClient) BuildNotificationMessage(orders []*model.Or
 		result += groupHtml
 
 		// 添加订单详情
-		for _, order := range groupOrders {
+		for _, order := range group.Orders {
 			openTime := order.FirstSeenAt.Format("15:04:05")
 
 			orderDetailHtml := fmt.Sprintf(`<div style="display: flex; flex-wrap: wrap; gap: 4px; margin-bottom: 4px;">
@@ -142,6 +117,9 @@ func (s *WxPusherNotificationClient) BuildNotificationMessage(orders []*model.Or
 <span style="background-color: #e9ecef; padding: 2px 6px; border-radius: 8px; color: rgb(20,20,20); font-size: 11px;">开仓: %s</span>
 </div>
 `, order.OpenLeverage, order.OpenPrice, openTime)
+			if !isOpen {
+				orderDetailHtml += buildCloseDetailHtml(order)
+			}
 
 			// 检查长度
 			if len(result)+len(orderDetailHtml) >= maxLength {
@@ -167,6 +145,49 @@ func (s *WxPusherNotificationClient) BuildNotificationMessage(orders []*model.Or
 	return result
 }
 
+// BuildOrderChangeMessage 构建持仓变化通知消息
+func (s *WxPusherNotificationClient) BuildOrderChangeMessage(changes []*model.OrderChangeEvent) string {
+	if len(changes) == 0 {
+		return ""
+	}
+
+	const maxLength = 40000
+
+	result := fmt.Sprintf(`<div style="border: 2px solid #f39c12; border-radius: 8px; padding: 8px; background-color: #f8f9fa; margin: 5px 0;">
+<h3 style="color: #f39c12; margin: 0 0 6px 0; padding: 0;">🔄 持仓变化提醒</h3>
+<div style="color: #333; font-weight: bold; margin-bottom: 8px;">交易员: %s</div>
+`, changes[0].TraderName)
+
+	for _, change := range changes {
+		directionColor := "#dc3545" // 红色
+		if change.PositionSide == "LONG" {
+			directionColor = "#28a745" // 绿色
+		}
+
+		changeHtml := fmt.Sprintf(`<div style="border: 1px solid #dee2e6; border-radius: 6px; padding: 10px; margin: 6px 0; background-color: #ffffff;">
+<div style="display: flex; align-items: center; margin-bottom: 8px;">
+<span style="color: #f39c12; font-weight: bold; font-size: 14px; margin-right: 8px;">%s</span>
+<span style="background-color: %s; color: white; padding: 2px 8px; border-radius: 12px; font-size: 12px; font-weight: bold;">%s</span>
+<span style="margin-left: 8px; color: #333; font-size: 12px; font-weight: bold;">%s</span>
+</div>
+<div style="display: flex; flex-wrap: wrap; gap: 4px; margin-bottom: 4px;">
+<span style="background-color: #e9ecef; padding: 2px 6px; border-radius: 8px; color: rgb(20,20,20); font-size: 11px;">%s → %s</span>
+<span style="background-color: #e9ecef; padding: 2px 6px; border-radius: 8px; color: rgb(20,20,20); font-size: 11px;">时间: %s</span>
+</div>
+</div>
+`, change.ContractSymbol, directionColor, directionText(change.PositionSide), change.ChangeType.Label(),
+			displayValue(change.OldValue), displayValue(change.NewValue), change.DetectedAt.Format("15:04:05"))
+
+		// 检查长度
+		if len(result)+len(changeHtml) >= maxLength {
+			break
+		}
+		result += changeHtml
+	}
+
+	return result + "</div>"
+}
+
 // getActionIcon 获取操作图标
 func getActionIcon(isOpen bool) string {
 	if isOpen {
@@ -177,13 +198,14 @@ func getActionIcon(isOpen bool) string {
 
 // sendMessage 发送消息
 func (s *WxPusherNotificationClient) SendMessage(notification NotificationMessage) error {
-	if s.appToken == "" || s.uid == "" {
+	uid := notification.targets([]string{s.uid})[0]
+	if s.appToken == "" || uid == "" {
 		return errors.New("wxpusher configuration is incomplete")
 	}
 	if notification.Message == "" {
 		return errors.New("notification message cannot be empty")
 	}
-	msg := wxpusher_model.NewMessage(s.appToken).SetContent(notification.Message).AddUId(s.uid)
+	msg := wxpusher_model.NewMessage(s.appToken).SetContent(notification.Message).AddUId(uid)
 	msgArr, err := wxpusher.SendMessage(msg)
 	if err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
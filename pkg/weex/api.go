@@ -1,166 +1,316 @@
 package weex
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
+
+	"weex-watchdog/pkg/logger"
 )
 
-// GetOpenOrderList 获取持仓中订单列表
-func GetOpenOrderList(traderUserId uint) ([]OpenOrder, error) {
-	url := "https://http-gateway1.janapw.com/api/v1/public/trace/getOpenOrderList"
-	method := "POST"
+const (
+	// DefaultBaseURL 默认网关地址
+	DefaultBaseURL = "https://http-gateway1.janapw.com"
+	// DefaultTimeout 默认请求超时时间
+	DefaultTimeout = 30 * time.Second
+	// DefaultPageSize 默认分页大小
+	DefaultPageSize = 100
+	// maxPages 单次查询最多翻页数，防止网关 nextFlag 异常时死循环
+	maxPages = 1000
+)
 
-	// 构建请求体，使用传入的 traderUserId
-	request := GetOpenOrderListRequest{
-		TraderUserID: strconv.FormatUint(uint64(traderUserId), 10),
-		PageNo:       1,
-		PageSize:     9999,
-		ContractId:   "",
-		LanguageType: 1,
-	}
+// defaultHeaders 网关要求的默认请求头
+var defaultHeaders = map[string]string{
+	"appversion":   "2.0.0",
+	"vs":           "A5a7fdv8uvY0GK93vYra79kVV4dQ76ir",
+	"content-type": "application/json;charset=UTF-8",
+}
 
-	requestBytes, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
+// API Weex网关接口
+type API interface {
+	GetOpenOrderList(ctx context.Context, traderUserId uint) ([]OpenOrder, error)
+	GetHistoryOrderPage(ctx context.Context, traderUserId string, pageNo, pageSize int) (*HistoryOrderPage, error)
+	GetMetaDataV2(ctx context.Context) (map[string]string, error)
+}
 
-	payload := strings.NewReader(string(requestBytes))
+// Config Weex API配置
+type Config struct {
+	APIURL         string            `mapstructure:"api_url"`
+	Timeout        time.Duration     `mapstructure:"timeout"`
+	RetryTimes     int               `mapstructure:"retry_times"`      // 失败后最大重试次数
+	RetryBaseDelay time.Duration     `mapstructure:"retry_base_delay"` // 首次重试等待时间，之后指数增长
+	RetryMaxDelay  time.Duration     `mapstructure:"retry_max_delay"`  // 单次重试等待上限
+	PageSize       int               `mapstructure:"page_size"`        // 分页大小
+	RateLimit      float64           `mapstructure:"rate_limit"`       // 全局每秒请求数上限，0 表示不限流
+	RateBurst      int               `mapstructure:"rate_burst"`       // 令牌桶容量
+	Headers        map[string]string `mapstructure:"headers"`          // 额外请求头，覆盖默认值
+	Transport      http.RoundTripper `mapstructure:"-"`                // 自定义传输层，为空时使用默认传输层
+}
 
-	client := &http.Client{}
-	req, err := http.NewRequest(method, url, payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// Client Weex网关HTTP客户端
+type Client struct {
+	baseURL    string
+	headers    map[string]string
+	httpClient *http.Client
+	pageSize   int
+	limiter    *RateLimiter
+	retry      RetryPolicy
+	logger     *logger.Logger
+}
+
+// NewClient 创建Weex网关客户端
+func NewClient(config *Config, logger *logger.Logger) *Client {
+	baseURL := normalizeBaseURL(config.APIURL)
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
 	}
 
-	req.Header.Add("appversion", "2.0.0")
-	req.Header.Add("vs", "A5a7fdv8uvY0GK93vYra79kVV4dQ76ir")
-	req.Header.Add("content-type", "application/json;charset=UTF-8")
+	headers := make(map[string]string, len(defaultHeaders)+len(config.Headers))
+	for k, v := range defaultHeaders {
+		headers[k] = v
+	}
+	for k, v := range config.Headers {
+		headers[strings.ToLower(k)] = v
+	}
 
-	res, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+	pageSize := config.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
 	}
-	defer res.Body.Close()
 
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	retry := RetryPolicy{
+		MaxRetries: config.RetryTimes,
+		BaseDelay:  config.RetryBaseDelay,
+		MaxDelay:   config.RetryMaxDelay,
+	}
+	if retry.MaxRetries < 0 {
+		retry.MaxRetries = 0
+	}
+	if retry.BaseDelay <= 0 {
+		retry.BaseDelay = DefaultRetryBaseDelay
+	}
+	if retry.MaxDelay <= 0 {
+		retry.MaxDelay = DefaultRetryMaxDelay
 	}
 
-	// 解析响应
-	var response GetOpenOrderListResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	return &Client{
+		baseURL: baseURL,
+		headers: headers,
+		httpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: config.Transport,
+		},
+		pageSize: pageSize,
+		limiter:  NewRateLimiter(config.RateLimit, config.RateBurst),
+		retry:    retry,
+		logger:   logger,
+	}
+}
+
+// normalizeBaseURL 规范化网关地址，兼容旧配置中填写的完整接口地址
+func normalizeBaseURL(apiURL string) string {
+	if apiURL == "" {
+		return DefaultBaseURL
 	}
+	if idx := strings.Index(apiURL, "/api/"); idx > 0 {
+		apiURL = apiURL[:idx]
+	}
+	return strings.TrimRight(apiURL, "/")
+}
 
-	// 检查API响应状态
-	if response.Code != "SUCCESS" {
-		return nil, fmt.Errorf("API returned error: code=%s, msg=%s", response.Code, response.Msg)
+// GetOpenOrderList 获取持仓中订单列表，自动跟随 totals/nextFlag 翻页
+func (c *Client) GetOpenOrderList(ctx context.Context, traderUserId uint) ([]OpenOrder, error) {
+	var orders []OpenOrder
+	for pageNo := 1; pageNo <= maxPages; pageNo++ {
+		// 构建请求体，使用传入的 traderUserId
+		request := GetOpenOrderListRequest{
+			TraderUserID: strconv.FormatUint(uint64(traderUserId), 10),
+			PageNo:       pageNo,
+			PageSize:     c.pageSize,
+			ContractId:   "",
+			LanguageType: 1,
+		}
+
+		var response GetOpenOrderListResponse
+		if err := c.doRequest(ctx, http.MethodPost, "/api/v1/public/trace/getOpenOrderList", request, &response); err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, response.Data.Rows...)
+		if !response.Data.NextFlag || len(response.Data.Rows) == 0 {
+			break
+		}
+		if response.Data.Totals > 0 && len(orders) >= response.Data.Totals {
+			break
+		}
 	}
 
-	return response.Data.Rows, nil
+	return orders, nil
 }
 
-// GetHistoryOrderList 获取历史订单列表
-func GetHistoryOrderList(traderUserId string) ([]OpenOrder, error) {
-	url := "https://http-gateway1.janapw.com/api/v1/public/trace/getHistoryOrderList"
-	method := "POST"
+// GetHistoryOrderPage 获取一页历史订单，pageSize 为0时使用客户端默认值
+func (c *Client) GetHistoryOrderPage(ctx context.Context, traderUserId string, pageNo, pageSize int) (*HistoryOrderPage, error) {
+	if pageSize <= 0 {
+		pageSize = c.pageSize
+	}
 
 	// 构建请求体，使用传入的 traderUserId
 	request := GetHistoryOrderListRequest{
-		TraderUserID: traderUserId,
+		TraderUserID:  traderUserId,
 		CurrentUserID: traderUserId,
-		PageSize:     9999,
-		LanguageType: 1,
+		PageNo:        pageNo,
+		PageSize:      pageSize,
+		LanguageType:  1,
 	}
 
-	requestBytes, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	var response GetHistoryOrderListResponse
+	if err := c.doRequest(ctx, http.MethodPost, "/api/v1/public/trace/getHistoryOrderList", request, &response); err != nil {
+		return nil, err
 	}
 
-	payload := strings.NewReader(string(requestBytes))
+	return &HistoryOrderPage{
+		Rows:     response.Data.Rows,
+		NextFlag: response.Data.NextFlag && pageNo < maxPages,
+	}, nil
+}
 
-	client := &http.Client{}
-	req, err := http.NewRequest(method, url, payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// GetMetaDataV2 获取合约id -> 交易对
+func (c *Client) GetMetaDataV2(ctx context.Context) (map[string]string, error) {
+	var response GetMetaDataV2Response
+	if err := c.doRequest(ctx, http.MethodGet, "/api/v1/public/meta/getMetaDataV2?languageType=1", nil, &response); err != nil {
+		return nil, err
 	}
 
-	req.Header.Add("appversion", "2.0.0")
-	req.Header.Add("vs", "A5a7fdv8uvY0GK93vYra79kVV4dQ76ir")
-	req.Header.Add("content-type", "application/json;charset=UTF-8")
-
-	res, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+	code2Name := make(map[string]string)
+	for _, item := range response.Data.ContractList {
+		code2Name[item.Ci] = item.Cn
 	}
-	defer res.Body.Close()
 
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
+	return code2Name, nil
+}
 
-	// 解析响应
-	var response GetHistoryOrderListResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+// doRequest 发送请求并解析响应，可重试的错误按重试策略退避重试
+func (c *Client) doRequest(ctx context.Context, method, path string, request interface{}, response baseResponse) error {
+	var requestBytes []byte
+	if request != nil {
+		var err error
+		requestBytes, err = json.Marshal(request)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
 	}
 
-	// 检查API响应状态
-	if response.Code != "SUCCESS" {
-		return nil, fmt.Errorf("API returned error: code=%s, msg=%s", response.Code, response.Msg)
+	maxAttempts := c.retry.MaxRetries + 1
+	for attempt := 1; ; attempt++ {
+		// 每次尝试（包括重试）都要先从全局令牌桶取令牌
+		wait, err := c.limiter.Wait(ctx)
+		if err != nil {
+			return fmt.Errorf("rate limiter wait aborted: %w", err)
+		}
+		if wait > 0 && c.logger != nil {
+			c.logger.WithFields(map[string]interface{}{
+				"path": path,
+				"wait": wait.String(),
+			}).Debug("Weex request throttled by rate limiter")
+		}
+
+		err = c.doOnce(ctx, method, path, requestBytes, response)
+		if err == nil {
+			if attempt > 1 && c.logger != nil {
+				c.logger.WithFields(map[string]interface{}{
+					"path":     path,
+					"attempts": attempt,
+				}).Info("Weex request succeeded after retry")
+			}
+			return nil
+		}
+
+		if !IsRetryable(err) {
+			return err
+		}
+		if attempt >= maxAttempts {
+			if c.logger != nil {
+				c.logger.WithFields(map[string]interface{}{
+					"path":     path,
+					"attempts": attempt,
+					"error":    err,
+				}).Error("Weex request failed after all retries")
+			}
+			return fmt.Errorf("request failed after %d attempts: %w", attempt, err)
+		}
+
+		delay := c.retry.Backoff(attempt)
+		if c.logger != nil {
+			c.logger.WithFields(map[string]interface{}{
+				"path":         path,
+				"attempt":      attempt,
+				"max_attempts": maxAttempts,
+				"retry_in":     delay.String(),
+				"error":        err,
+			}).Warn("Weex request failed, retrying")
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return fmt.Errorf("request aborted after %d attempts: %w", attempt, err)
+		}
 	}
-
-	return response.Data.Rows, nil
 }
 
-// GetMetaDataV2 获取合约id -> 交易对
-func GetMetaDataV2() (map[string]string, error) {
-	url := "https://http-gateway1.janapw.com/api/v1/public/meta/getMetaDataV2?languageType=1"
-	method := "GET"
+// doOnce 发送单次请求
+func (c *Client) doOnce(ctx context.Context, method, path string, requestBytes []byte, response baseResponse) error {
+	var payload io.Reader
+	if requestBytes != nil {
+		payload = bytes.NewReader(requestBytes)
+	}
 
-	client := &http.Client{}
-	req, err := http.NewRequest(method, url, nil)
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, payload)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Add("appversion", "2.0.0")
-	req.Header.Add("vs", "A5a7fdv8uvY0GK93vYra79kVV4dQ76ir")
-	req.Header.Add("content-type", "application/json;charset=UTF-8")
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
 
-	res, err := client.Do(req)
+	res, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return fmt.Errorf("failed to send request: %w", err)
 	}
 	defer res.Body.Close()
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return &StatusError{StatusCode: res.StatusCode, Body: truncate(string(body), 256)}
 	}
 
 	// 解析响应
-	var response GetMetaDataV2Response
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	if err := json.Unmarshal(body, response); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	// 检查API响应状态
-	if response.Code != "SUCCESS" {
-		return nil, fmt.Errorf("API returned error: code=%s, msg=%s", response.Code, response.Msg)
+	if base := response.base(); base.Code != "SUCCESS" {
+		return &APIError{Code: base.Code, Msg: base.Msg}
 	}
 
-	code2Name := make(map[string]string)
-	for _, item := range response.Data.ContractList {
-		code2Name[item.Ci] = item.Cn
-	}
+	return nil
+}
 
-	return code2Name, nil
+// truncate 截断过长的字符串，用于错误信息
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
 }
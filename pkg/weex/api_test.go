@@ -0,0 +1,410 @@
+package weex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeGateway 假网关，按路径返回响应并记录收到的请求
+type fakeGateway struct {
+	t        *testing.T
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []map[string]interface{}
+	handle   func(w http.ResponseWriter, path string, body map[string]interface{})
+}
+
+func (g *fakeGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	if r.Body != nil {
+		data, _ := io.ReadAll(r.Body)
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &body); err != nil {
+				g.t.Errorf("invalid request body %q: %v", data, err)
+			}
+		}
+	}
+
+	g.mu.Lock()
+	g.requests = append(g.requests, r)
+	g.bodies = append(g.bodies, body)
+	g.mu.Unlock()
+
+	g.handle(w, r.URL.Path, body)
+}
+
+// calls 已收到的请求数
+func (g *fakeGateway) calls() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.requests)
+}
+
+// writeJSON 写入网关响应
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// historyResponse 历史订单分页响应
+func historyResponse(nextFlag bool, rows ...OpenOrder) map[string]interface{} {
+	return map[string]interface{}{
+		"code": "SUCCESS",
+		"data": map[string]interface{}{"nextFlag": nextFlag, "rows": rows},
+	}
+}
+
+func newTestGateway(t *testing.T, handle func(w http.ResponseWriter, path string, body map[string]interface{})) (*fakeGateway, *httptest.Server) {
+	t.Helper()
+	gateway := &fakeGateway{t: t, handle: handle}
+	server := httptest.NewServer(gateway)
+	t.Cleanup(server.Close)
+	return gateway, server
+}
+
+func newTestClient(config *Config) *Client {
+	if config.Timeout == 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.RetryBaseDelay == 0 {
+		config.RetryBaseDelay = time.Millisecond
+		config.RetryMaxDelay = 2 * time.Millisecond
+	}
+	return NewClient(config, nil)
+}
+
+func TestNormalizeBaseURL(t *testing.T) {
+	tests := []struct {
+		apiURL string
+		want   string
+	}{
+		{"", DefaultBaseURL},
+		{"https://gateway.example.com", "https://gateway.example.com"},
+		{"https://gateway.example.com/", "https://gateway.example.com"},
+		{"https://gateway.example.com/api/v1/public/trace/getOpenOrderList", "https://gateway.example.com"},
+		{"http://127.0.0.1:8080/proxy/api/v1/public/trace/getHistoryOrderList", "http://127.0.0.1:8080/proxy"},
+	}
+	for _, tt := range tests {
+		if got := normalizeBaseURL(tt.apiURL); got != tt.want {
+			t.Errorf("normalizeBaseURL(%q) = %q, want %q", tt.apiURL, got, tt.want)
+		}
+	}
+}
+
+func TestClientLegacyAPIURL(t *testing.T) {
+	gateway, server := newTestGateway(t, func(w http.ResponseWriter, path string, body map[string]interface{}) {
+		writeJSON(w, map[string]interface{}{
+			"code": "SUCCESS",
+			"data": map[string]interface{}{
+				"contractList": []map[string]string{{"ci": "10000001", "cn": "BTCUSDT"}},
+			},
+		})
+	})
+
+	// 旧配置填写的是完整的接口地址
+	client := newTestClient(&Config{APIURL: server.URL + "/api/v1/public/trace/getOpenOrderList"})
+	symbols, err := client.GetMetaDataV2(context.Background())
+	if err != nil {
+		t.Fatalf("GetMetaDataV2: %v", err)
+	}
+	if symbols["10000001"] != "BTCUSDT" {
+		t.Errorf("symbols = %v", symbols)
+	}
+
+	req := gateway.requests[0]
+	if req.Method != http.MethodGet || req.URL.Path != "/api/v1/public/meta/getMetaDataV2" || req.URL.Query().Get("languageType") != "1" {
+		t.Errorf("request = %s %s, want GET /api/v1/public/meta/getMetaDataV2?languageType=1", req.Method, req.URL)
+	}
+}
+
+func TestClientHeaders(t *testing.T) {
+	gateway, server := newTestGateway(t, func(w http.ResponseWriter, path string, body map[string]interface{}) {
+		writeJSON(w, historyResponse(false))
+	})
+
+	client := newTestClient(&Config{
+		APIURL: server.URL,
+		Headers: map[string]string{
+			"AppVersion": "3.1.0",
+			"X-Trace":    "watchdog",
+		},
+	})
+	if _, err := client.GetHistoryOrderPage(context.Background(), "42", 1, 0); err != nil {
+		t.Fatalf("GetHistoryOrderPage: %v", err)
+	}
+
+	header := gateway.requests[0].Header
+	wantHeaders := map[string]string{
+		"appversion":   "3.1.0", // 配置覆盖默认值，忽略大小写
+		"vs":           defaultHeaders["vs"],
+		"content-type": defaultHeaders["content-type"],
+		"x-trace":      "watchdog",
+	}
+	for name, want := range wantHeaders {
+		if got := header.Values(name); len(got) != 1 || got[0] != want {
+			t.Errorf("header %s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestGetHistoryOrderPageRequest(t *testing.T) {
+	gateway, server := newTestGateway(t, func(w http.ResponseWriter, path string, body map[string]interface{}) {
+		if path != "/api/v1/public/trace/getHistoryOrderList" {
+			http.NotFound(w, nil)
+			return
+		}
+		writeJSON(w, historyResponse(true, OpenOrder{ID: "1"}, OpenOrder{ID: "2"}))
+	})
+
+	client := newTestClient(&Config{APIURL: server.URL, PageSize: 50})
+	page, err := client.GetHistoryOrderPage(context.Background(), "42", 3, 0)
+	if err != nil {
+		t.Fatalf("GetHistoryOrderPage: %v", err)
+	}
+	if len(page.Rows) != 2 || !page.NextFlag {
+		t.Errorf("page = %+v, want 2 rows with next page", page)
+	}
+
+	body := gateway.bodies[0]
+	if body["traderUserId"] != "42" || body["currentUserId"] != "42" || body["pageNo"] != 3.0 || body["pageSize"] != 50.0 {
+		t.Errorf("request body = %v", body)
+	}
+
+	// 超过最大页数时不再翻页
+	page, err = client.GetHistoryOrderPage(context.Background(), "42", maxPages, 0)
+	if err != nil {
+		t.Fatalf("GetHistoryOrderPage: %v", err)
+	}
+	if page.NextFlag {
+		t.Error("NextFlag = true on the last allowed page")
+	}
+}
+
+func TestGetOpenOrderListPaging(t *testing.T) {
+	gateway, server := newTestGateway(t, func(w http.ResponseWriter, path string, body map[string]interface{}) {
+		pageNo := int(body["pageNo"].(float64))
+		rows := []OpenOrder{{ID: strconv.Itoa(pageNo*10 + 1)}, {ID: strconv.Itoa(pageNo*10 + 2)}}
+		writeJSON(w, map[string]interface{}{
+			"code": "SUCCESS",
+			"data": map[string]interface{}{"nextFlag": true, "totals": 5, "rows": rows},
+		})
+	})
+
+	client := newTestClient(&Config{APIURL: server.URL, PageSize: 2})
+	orders, err := client.GetOpenOrderList(context.Background(), 42)
+	if err != nil {
+		t.Fatalf("GetOpenOrderList: %v", err)
+	}
+	// 达到 totals 后停止翻页
+	if len(orders) != 6 || gateway.calls() != 3 {
+		t.Errorf("got %d orders in %d requests, want 6 in 3", len(orders), gateway.calls())
+	}
+	if gateway.bodies[0]["traderUserId"] != "42" {
+		t.Errorf("traderUserId = %v, want \"42\"", gateway.bodies[0]["traderUserId"])
+	}
+}
+
+func TestHistoryIteratorSince(t *testing.T) {
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) string {
+		return strconv.FormatInt(since.Add(d).UnixMilli(), 10)
+	}
+
+	// 网关按时间倒序返回，第二页跨过时间下限，第三页整页都更早
+	pages := [][]OpenOrder{
+		{{ID: "1", OpenTime: at(-time.Hour), CloseTime: at(3 * time.Hour)}, {ID: "2", OpenTime: at(2 * time.Hour)}},
+		{{ID: "3", OpenTime: at(time.Hour)}, {ID: "4", OpenTime: at(-2 * time.Hour), CloseTime: at(-time.Hour)}},
+		{{ID: "5", OpenTime: at(-3 * time.Hour)}, {ID: "6", OpenTime: at(-4 * time.Hour)}},
+		{{ID: "7", OpenTime: at(-5 * time.Hour)}},
+	}
+
+	tests := []struct {
+		name      string
+		since     time.Time
+		wantIDs   []string
+		wantPages int
+	}{
+		{
+			name:      "stops at first page older than since",
+			since:     since,
+			wantIDs:   []string{"1", "2", "3"},
+			wantPages: 3,
+		},
+		{
+			name:      "zero since reads all pages",
+			wantIDs:   []string{"1", "2", "3", "4", "5", "6", "7"},
+			wantPages: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway, server := newTestGateway(t, func(w http.ResponseWriter, path string, body map[string]interface{}) {
+				pageNo := int(body["pageNo"].(float64))
+				if pageNo > len(pages) {
+					writeJSON(w, historyResponse(false))
+					return
+				}
+				writeJSON(w, historyResponse(pageNo < len(pages), pages[pageNo-1]...))
+			})
+			client := newTestClient(&Config{APIURL: server.URL})
+
+			orders, err := ListHistoryOrders(context.Background(), client, "42", HistoryQuery{PageSize: 2, Since: tt.since})
+			if err != nil {
+				t.Fatalf("ListHistoryOrders: %v", err)
+			}
+			var ids []string
+			for _, order := range orders {
+				ids = append(ids, order.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("orders = %v, want %v", ids, tt.wantIDs)
+			}
+			if gateway.calls() != tt.wantPages {
+				t.Errorf("fetched %d pages, want %d", gateway.calls(), tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestHistoryIteratorError(t *testing.T) {
+	_, server := newTestGateway(t, func(w http.ResponseWriter, path string, body map[string]interface{}) {
+		if body["pageNo"].(float64) == 2 {
+			writeJSON(w, map[string]interface{}{"code": "PARAM_ERROR", "msg": "bad page"})
+			return
+		}
+		writeJSON(w, historyResponse(true, OpenOrder{ID: "1"}))
+	})
+	client := newTestClient(&Config{APIURL: server.URL})
+
+	it := NewHistoryIterator(client, "42", HistoryQuery{})
+	var count int
+	for it.Next(context.Background()) {
+		count++
+	}
+	var apiErr *APIError
+	if count != 1 || !errors.As(it.Err(), &apiErr) || apiErr.Code != "PARAM_ERROR" {
+		t.Fatalf("read %d orders, err = %v; want 1 order then APIError", count, it.Err())
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []func(w http.ResponseWriter)
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name: "retries server errors",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) },
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusTooManyRequests) },
+				func(w http.ResponseWriter) { writeJSON(w, historyResponse(false)) },
+			},
+			wantCalls: 3,
+		},
+		{
+			name: "gives up after retry times",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) },
+			},
+			wantCalls: 3,
+			wantErr:   true,
+		},
+		{
+			name: "does not retry client errors",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadRequest) },
+			},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name: "does not retry API errors",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					writeJSON(w, map[string]interface{}{"code": "FAIL", "msg": "trader not found"})
+				},
+			},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu    sync.Mutex
+				calls int
+			)
+			gateway, server := newTestGateway(t, func(w http.ResponseWriter, path string, body map[string]interface{}) {
+				mu.Lock()
+				respond := tt.responses[min(calls, len(tt.responses)-1)]
+				calls++
+				mu.Unlock()
+				respond(w)
+			})
+			client := newTestClient(&Config{APIURL: server.URL, RetryTimes: 2})
+
+			_, err := client.GetHistoryOrderPage(context.Background(), "42", 1, 0)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if gateway.calls() != tt.wantCalls {
+				t.Errorf("gateway called %d times, want %d", gateway.calls(), tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	deadline, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-deadline.Done()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"api error", &APIError{Code: "FAIL"}, false},
+		{"wrapped api error", fmt.Errorf("request failed: %w", &APIError{Code: "FAIL"}), false},
+		{"bad request", &StatusError{StatusCode: http.StatusBadRequest}, false},
+		{"not found", &StatusError{StatusCode: http.StatusNotFound}, false},
+		{"too many requests", &StatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"internal error", &StatusError{StatusCode: http.StatusInternalServerError}, true},
+		{"bad gateway", &StatusError{StatusCode: http.StatusBadGateway}, true},
+		{"canceled", context.Canceled, false},
+		{"deadline exceeded", deadline.Err(), true},
+		{"net timeout", &net.OpError{Op: "read", Err: timeoutError{}}, true},
+		{"connection reset", fmt.Errorf("failed to send request: %w", &net.OpError{Op: "read", Err: syscall.ECONNRESET}), true},
+		{"connection refused", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, true},
+		{"unexpected eof", fmt.Errorf("failed to read response body: %w", io.ErrUnexpectedEOF), true},
+		{"unmarshal error", errors.New("failed to unmarshal response"), false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("%s: IsRetryable(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+// timeoutError 实现 net.Error 的超时错误
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
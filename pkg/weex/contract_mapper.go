@@ -1,6 +1,7 @@
 package weex
 
 import (
+	"context"
 	"sync"
 )
 
@@ -27,8 +28,8 @@ func GetContractMapper() *ContractMapper {
 }
 
 // LoadContractMapping 加载合约映射
-func (cm *ContractMapper) LoadContractMapping() error {
-	contractMap, err := GetMetaDataV2()
+func (cm *ContractMapper) LoadContractMapping(ctx context.Context, api API) error {
+	contractMap, err := api.GetMetaDataV2(ctx)
 	if err != nil {
 		return err
 	}
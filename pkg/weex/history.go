@@ -0,0 +1,134 @@
+package weex
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// HistoryQuery 历史订单查询条件
+type HistoryQuery struct {
+	// PageSize 每页条数，为0时使用客户端默认值
+	PageSize int
+	// Since 时间下限，为零值时拉取全部历史；
+	// 网关按时间倒序返回，一旦整页订单都早于该时间即停止翻页
+	Since time.Time
+}
+
+// HistoryOrderPage 历史订单单页结果
+type HistoryOrderPage struct {
+	Rows     []OpenOrder
+	NextFlag bool
+}
+
+// HistoryIterator 历史订单分页迭代器
+//
+//	it := weex.NewHistoryIterator(api, traderID, weex.HistoryQuery{Since: since})
+//	for it.Next(ctx) {
+//		order := it.Order()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type HistoryIterator struct {
+	api          API
+	traderUserID string
+	query        HistoryQuery
+
+	pageNo  int
+	buf     []OpenOrder
+	current OpenOrder
+	done    bool
+	err     error
+}
+
+// NewHistoryIterator 创建历史订单迭代器
+func NewHistoryIterator(api API, traderUserID string, query HistoryQuery) *HistoryIterator {
+	return &HistoryIterator{
+		api:          api,
+		traderUserID: traderUserID,
+		query:        query,
+	}
+}
+
+// Next 移动到下一条订单，没有更多数据或出错时返回 false
+func (it *HistoryIterator) Next(ctx context.Context) bool {
+	for len(it.buf) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+		it.fetchPage(ctx)
+	}
+
+	it.current = it.buf[0]
+	it.buf = it.buf[1:]
+	return true
+}
+
+// Order 返回当前订单
+func (it *HistoryIterator) Order() OpenOrder {
+	return it.current
+}
+
+// Err 返回迭代过程中遇到的错误
+func (it *HistoryIterator) Err() error {
+	return it.err
+}
+
+// fetchPage 拉取下一页，并根据时间下限过滤
+func (it *HistoryIterator) fetchPage(ctx context.Context) {
+	it.pageNo++
+	page, err := it.api.GetHistoryOrderPage(ctx, it.traderUserID, it.pageNo, it.query.PageSize)
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	if !page.NextFlag || len(page.Rows) == 0 {
+		it.done = true
+	}
+
+	if it.query.Since.IsZero() {
+		it.buf = page.Rows
+		return
+	}
+
+	for _, order := range page.Rows {
+		if !orderActivityTime(order).Before(it.query.Since) {
+			it.buf = append(it.buf, order)
+		}
+	}
+	// 整页都早于时间下限，后续页只会更早
+	if len(it.buf) == 0 {
+		it.done = true
+	}
+}
+
+// ListHistoryOrders 按条件拉取全部历史订单
+func ListHistoryOrders(ctx context.Context, api API, traderUserID string, query HistoryQuery) ([]OpenOrder, error) {
+	var orders []OpenOrder
+	it := NewHistoryIterator(api, traderUserID, query)
+	for it.Next(ctx) {
+		orders = append(orders, it.Order())
+	}
+	return orders, it.Err()
+}
+
+// orderActivityTime 订单最后活动时间：已平仓取平仓时间，否则取开仓时间
+func orderActivityTime(order OpenOrder) time.Time {
+	if t, ok := ParseMillis(order.CloseTime); ok {
+		return t
+	}
+	t, _ := ParseMillis(order.OpenTime)
+	return t
+}
+
+// ParseMillis 解析毫秒时间戳字符串
+func ParseMillis(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms), true
+}
@@ -0,0 +1,52 @@
+package weex
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	// DefaultRetryBaseDelay 默认首次重试等待时间
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+	// DefaultRetryMaxDelay 默认单次重试最长等待时间
+	DefaultRetryMaxDelay = 10 * time.Second
+)
+
+// RetryPolicy 重试策略：指数退避 + 随机抖动
+type RetryPolicy struct {
+	MaxRetries int           // 首次请求失败后的最大重试次数
+	BaseDelay  time.Duration // 首次重试等待时间
+	MaxDelay   time.Duration // 单次等待时间上限
+}
+
+// Backoff 计算第 retry 次重试（从1开始）前的等待时间
+// 采用 equal jitter：等待时间在 [d/2, d) 之间随机，d = min(MaxDelay, BaseDelay * 2^(retry-1))
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + rand.N(half)
+}
+
+// sleep 等待指定时间，ctx 取消时提前返回
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -5,6 +5,15 @@ type WeexBaseResponse struct {
 	Msg  string `json:"msg"`
 }
 
+// baseResponse 所有网关响应共有的状态字段
+type baseResponse interface {
+	base() WeexBaseResponse
+}
+
+func (r WeexBaseResponse) base() WeexBaseResponse {
+	return r
+}
+
 type GetOpenOrderListRequest struct {
 	TraderUserID string `json:"traderUserId"`
 	PageNo       int    `json:"pageNo"`
@@ -25,6 +34,7 @@ type GetOpenOrderListResponse struct {
 type GetHistoryOrderListRequest struct {
 	TraderUserID  string `json:"traderUserId"`
 	CurrentUserID string `json:"currentUserId"`
+	PageNo        int    `json:"pageNo"`
 	PageSize      int    `json:"pageSize"`
 	LanguageType  int    `json:"languageType"`
 }
@@ -5,6 +5,8 @@ CREATE TABLE trader_monitors (
     trader_name VARCHAR(100) COMMENT '交易员昵称',
     is_active BOOLEAN DEFAULT TRUE COMMENT '是否启用监控',
     monitor_interval INT DEFAULT 30 COMMENT '监控间隔(秒)',
+    notify_initial_snapshot BOOLEAN DEFAULT FALSE COMMENT '建立基线时是否推送已有持仓',
+    baseline_at TIMESTAMP NULL COMMENT '基线建立时间',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     INDEX idx_trader_user_id (trader_user_id),
@@ -27,10 +29,19 @@ CREATE TABLE order_history (
     first_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '首次发现时间',
     last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后更新时间',
     closed_at TIMESTAMP NULL COMMENT '平仓时间',
+    average_close_price VARCHAR(32) COMMENT '平仓均价',
+    realized_pnl VARCHAR(32) COMMENT '已实现盈亏',
+    close_fee VARCHAR(32) COMMENT '平仓手续费',
+    funding_fee VARCHAR(32) COMMENT '资金费用',
+    closed_by VARCHAR(30) COMMENT '平仓方式',
+    close_time TIMESTAMP NULL COMMENT '实际平仓时间',
+    reconcile_status VARCHAR(20) COMMENT '平仓对账状态',
+    reconcile_attempts INT DEFAULT 0 COMMENT '平仓对账尝试次数',
     UNIQUE KEY uk_trader_order (trader_user_id, order_id),
     INDEX idx_trader_user_id (trader_user_id),
     INDEX idx_status (status),
-    INDEX idx_first_seen_at (first_seen_at)
+    INDEX idx_first_seen_at (first_seen_at),
+    INDEX idx_reconcile_status (reconcile_status)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='订单历史记录表';
 
 -- 通知记录表
@@ -38,12 +49,164 @@ CREATE TABLE notification_logs (
     id BIGINT PRIMARY KEY AUTO_INCREMENT,
     trader_user_id VARCHAR(50) NOT NULL,
     order_id VARCHAR(50),
-    notification_type ENUM('NEW_ORDER', 'ORDER_CLOSED') NOT NULL,
-    message TEXT,
-    status ENUM('PENDING', 'SUCCESS', 'FAILED') DEFAULT 'PENDING',
+    channel VARCHAR(30) COMMENT '发送渠道',
+    notification_type VARCHAR(30) NOT NULL,
+    subscriber_id BIGINT NULL COMMENT '订阅人ID，为空表示渠道默认接收人',
+    recipient VARCHAR(255) COMMENT '接收地址',
+    batch_id VARCHAR(32) COMMENT '同一次发送的通知共用',
+    message MEDIUMTEXT COMMENT '渲染后的消息内容',
+    status VARCHAR(20) DEFAULT 'PENDING' COMMENT 'PENDING/SUCCESS/FAILED/DEAD/FILTERED',
+    attempts INT DEFAULT 0 COMMENT '已发送次数',
+    next_retry_at TIMESTAMP NULL COMMENT '下次重试时间',
     sent_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     error_msg TEXT,
+    delivered JSON NULL COMMENT '部分发送失败时已送达的接收地址和分段',
     INDEX idx_trader_user_id (trader_user_id),
+    INDEX idx_channel (channel),
+    INDEX idx_subscriber_id (subscriber_id),
+    INDEX idx_batch_id (batch_id),
+    INDEX idx_next_retry_at (next_retry_at),
     INDEX idx_notification_type (notification_type),
     INDEX idx_status (status)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='通知发送记录表';
+
+-- 持仓变化事件表
+CREATE TABLE order_change_events (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    trader_user_id VARCHAR(50) NOT NULL COMMENT '交易员ID',
+    trader_name VARCHAR(100) COMMENT '交易员昵称',
+    order_id VARCHAR(50) NOT NULL COMMENT '订单ID',
+    contract_symbol VARCHAR(50) COMMENT '合约标识',
+    position_side VARCHAR(10) COMMENT '持仓方向',
+    change_type VARCHAR(30) NOT NULL COMMENT '变化类型',
+    old_value VARCHAR(50) COMMENT '变化前的值',
+    new_value VARCHAR(50) COMMENT '变化后的值',
+    detected_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '发现时间',
+    INDEX idx_trader_user_id (trader_user_id),
+    INDEX idx_order_id (order_id),
+    INDEX idx_change_type (change_type),
+    INDEX idx_detected_at (detected_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='持仓变化事件表';
+
+-- 通知订阅人表
+CREATE TABLE subscribers (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(100) NOT NULL COMMENT '订阅人名称',
+    channel VARCHAR(30) NOT NULL COMMENT '接收渠道',
+    address VARCHAR(255) NOT NULL COMMENT '渠道内的接收地址',
+    is_active BOOLEAN DEFAULT TRUE COMMENT '是否启用',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_channel (channel),
+    INDEX idx_is_active (is_active)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='通知订阅人表';
+
+-- 通知订阅表
+CREATE TABLE subscriptions (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    subscriber_id BIGINT NOT NULL COMMENT '订阅人ID',
+    trader_user_id VARCHAR(50) NOT NULL COMMENT '交易员ID',
+    event_types JSON COMMENT '订阅的事件类型，为空时接收全部事件',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_subscriber_trader (subscriber_id, trader_user_id),
+    INDEX idx_trader_user_id (trader_user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='通知订阅表';
+
+-- 通知过滤规则表
+CREATE TABLE notification_rules (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(100) NOT NULL COMMENT '规则名称',
+    trader_user_id VARCHAR(50) COMMENT '交易员ID，为空时对所有交易员生效',
+    action VARCHAR(10) NOT NULL COMMENT 'INCLUDE/EXCLUDE',
+    contract_symbol VARCHAR(50) COMMENT '合约标识',
+    position_side VARCHAR(10) COMMENT '持仓方向',
+    min_leverage DOUBLE DEFAULT 0 COMMENT '最低杠杆',
+    min_notional DOUBLE DEFAULT 0 COMMENT '最低名义价值',
+    quiet_start VARCHAR(5) COMMENT '时段开始 HH:MM',
+    quiet_end VARCHAR(5) COMMENT '时段结束 HH:MM',
+    is_active BOOLEAN DEFAULT TRUE COMMENT '是否启用',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_trader_user_id (trader_user_id),
+    INDEX idx_is_active (is_active)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='通知过滤规则表';
+
+-- 用户表
+CREATE TABLE users (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    username VARCHAR(50) NOT NULL COMMENT '用户名',
+    password_hash VARCHAR(100) NOT NULL COMMENT 'bcrypt 密码哈希',
+    role VARCHAR(20) NOT NULL DEFAULT 'viewer' COMMENT '角色 admin/operator/viewer',
+    is_active BOOLEAN DEFAULT TRUE COMMENT '是否启用',
+    last_login_at TIMESTAMP NULL COMMENT '最近登录时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_username (username)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户表';
+
+-- 登录会话表
+CREATE TABLE user_sessions (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    access_token_hash CHAR(64) NOT NULL COMMENT '访问令牌 SHA-256',
+    refresh_token_hash CHAR(64) NOT NULL COMMENT '刷新令牌 SHA-256',
+    access_expires_at TIMESTAMP NOT NULL COMMENT '访问令牌过期时间',
+    refresh_expires_at TIMESTAMP NOT NULL COMMENT '刷新令牌过期时间',
+    revoked_at TIMESTAMP NULL COMMENT '注销时间',
+    client_ip VARCHAR(64) COMMENT '登录IP',
+    user_agent VARCHAR(255) COMMENT '客户端',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_access_token_hash (access_token_hash),
+    UNIQUE KEY uk_refresh_token_hash (refresh_token_hash),
+    INDEX idx_user_id (user_id),
+    INDEX idx_refresh_expires_at (refresh_expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='登录会话表';
+
+-- API 密钥表
+CREATE TABLE api_keys (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(100) NOT NULL COMMENT '密钥名称',
+    prefix VARCHAR(16) NOT NULL COMMENT '密钥开头几位，用于识别',
+    key_hash CHAR(64) NOT NULL COMMENT '密钥 SHA-256',
+    scopes JSON COMMENT '权限范围',
+    user_id BIGINT NOT NULL COMMENT '创建人',
+    expires_at TIMESTAMP NULL COMMENT '过期时间，为空时不过期',
+    last_used_at TIMESTAMP NULL COMMENT '最近使用时间',
+    revoked_at TIMESTAMP NULL COMMENT '吊销时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_key_hash (key_hash),
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='API 密钥表';
+
+-- 审计日志表
+CREATE TABLE audit_logs (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    actor_id BIGINT NULL COMMENT '操作用户，登录失败时为空',
+    actor_name VARCHAR(50) COMMENT '操作用户名',
+    api_key_id BIGINT NULL COMMENT '通过 API 密钥操作时的密钥',
+    action VARCHAR(50) NOT NULL COMMENT '操作类型，如 trader.delete',
+    target_type VARCHAR(30) COMMENT '操作对象类型',
+    target_id VARCHAR(64) COMMENT '操作对象ID',
+    changes JSON COMMENT '修改前后有差异的字段',
+    client_ip VARCHAR(64) COMMENT '客户端IP',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_actor_id (actor_id),
+    INDEX idx_actor_name (actor_name),
+    INDEX idx_action (action),
+    INDEX idx_audit_target (target_type, target_id),
+    INDEX idx_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='审计日志表';
+
+-- 登录失败计数表
+CREATE TABLE login_throttles (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    `key` VARCHAR(120) NOT NULL COMMENT 'user:{用户名} 或 ip:{IP}',
+    failures INT DEFAULT 0 COMMENT '当前窗口内的尝试次数，包括进行中的尝试',
+    lockouts INT DEFAULT 0 COMMENT '连续锁定次数',
+    locked_until TIMESTAMP NULL COMMENT '锁定截止时间',
+    last_failure_at TIMESTAMP NOT NULL COMMENT '最近失败时间',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_key (`key`),
+    INDEX idx_locked_until (locked_until),
+    INDEX idx_last_failure_at (last_failure_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='登录失败计数表';